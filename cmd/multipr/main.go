@@ -30,10 +30,14 @@ func run() error {
 	help := flag.Bool("help", false, "Show help")
 	jobFile := flag.String("job", "", "Path to the YAML job file (required)")
 	manualCommit := flag.Bool("manual-commit", false, "User manages git commits in shell commands")
+	prune := flag.Bool("prune", false, "Remove cloned repositories no longer matched by the search")
 	publish := flag.Bool("publish", false, "Publish PRs")
+	publishMode := flag.String(
+		"publish-mode", "", "Override the publish mode of the job file: pr, push, gerrit or email",
+	)
 	reviewSteps := flag.Bool("review", false, "Manual review of each major step")
 	showDiffs := flag.Bool("show-diffs", true, "Show each git diff")
-	skipSearch := flag.Bool("skip-search", false, "Skip search for repositories")
+	skipSearch := flag.Bool("skip-search", false, "Skip search, reusing the repositories found by the previous search")
 	workers := flag.Int("workers", 0, "Number of workers to use for concurrency (default: 2x CPU cores)")
 	shell := flag.String("shell", "bash", "Shell to use for executing commands")
 
@@ -66,7 +70,9 @@ func run() error {
 		Debug:        *debug,
 		Draft:        *draft,
 		ManualCommit: *manualCommit,
+		Prune:        *prune,
 		Publish:      *publish,
+		PublishMode:  *publishMode,
 		ReviewSteps:  *reviewSteps,
 		Shell:        *shell,
 		ShowDiffs:    *showDiffs,
@@ -79,7 +85,7 @@ func run() error {
 	defer cancel()
 
 	// Create and run the workflow
-	r, err := job.NewManager(cfg, opts, *jobFile)
+	r, err := job.NewManager(ctx, cfg, opts, *jobFile)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,127 @@
+package azuredevops_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/fredrikaverpil/multipr/internal/azuredevops"
+	"github.com/fredrikaverpil/multipr/internal/azuredevops/azuredevopstest"
+)
+
+func TestClient_ListRepositories(t *testing.T) {
+	server := azuredevopstest.NewServer(t)
+	for _, name := range []string{"acme/Platform/api", "acme/Platform/web", "acme/Legacy/api", "other/Platform/api"} {
+		org, project, repo := azuredevops.SplitFullName(name)
+		server.AddRepository(org, azuredevops.Repository{Name: repo, Project: azuredevops.Project{Name: project}}, nil)
+	}
+	client := azuredevops.NewClient(server.URL, azuredevopstest.Token)
+
+	tests := []struct {
+		name    string
+		project string
+		want    string
+	}{
+		{name: "organization", want: "acme/Legacy/api,acme/Platform/api,acme/Platform/web"},
+		{name: "project", project: "Platform", want: "acme/Platform/api,acme/Platform/web"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos, err := client.ListRepositories(t.Context(), "acme", tt.project)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range repos {
+				got = append(got, r.FullName("acme"))
+			}
+			if strings.Join(got, ",") != tt.want {
+				t.Fatalf("got %v, want %s", got, tt.want)
+			}
+		})
+	}
+
+	repo, err := client.GetRepository(t.Context(), "acme/Platform/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.DefaultBranch != "refs/heads/main" || repo.Project.Visibility != "private" {
+		t.Fatalf("unexpected repository: %+v", repo)
+	}
+
+	if _, err = client.GetRepository(t.Context(), "acme/Platform/missing"); !azuredevops.IsNotFound(err) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestClient_PullRequestLifecycle(t *testing.T) {
+	server := azuredevopstest.NewServer(t)
+	server.AddRepository("acme", azuredevops.Repository{Name: "api", Project: azuredevops.Project{Name: "Platform"}},
+		map[string]string{"README.md": "# api\n"})
+	jdoe := server.AddUser("jdoe")
+	asmith := server.AddUser("asmith")
+	client := azuredevops.NewClient(server.URL, azuredevopstest.Token)
+	ctx := t.Context()
+	const fullName = "acme/Platform/api"
+
+	pr, err := client.FindPullRequest(ctx, fullName, "main")
+	if err != nil || pr != nil {
+		t.Fatalf("expected no pull request, got %+v, %v", pr, err)
+	}
+
+	identity, err := client.FindIdentity(ctx, "acme", "jdoe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.ID != jdoe {
+		t.Fatalf("unexpected identity: %+v", identity)
+	}
+	if _, err = client.FindIdentity(ctx, "acme", "nobody"); err == nil {
+		t.Fatal("expected an error for an unknown user")
+	}
+
+	pr, err = client.CreatePullRequest(ctx, fullName, azuredevops.CreatePullRequestOptions{
+		Title: "chore: update", Description: "body", SourceBranch: "main", TargetBranch: "main",
+		ReviewerIDs: []string{jdoe}, Draft: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the given fields are updated
+	title := "chore: updated"
+	if _, err = client.UpdatePullRequest(ctx, fullName, pr.ID, azuredevops.UpdatePullRequestOptions{
+		Title: &title,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.AddReviewer(ctx, fullName, pr.ID, asmith); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := client.FindPullRequest(ctx, fullName, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.ID != pr.ID || found.Title != title || found.Description != "body" || !found.IsDraft {
+		t.Fatalf("unexpected pull request: %+v", found)
+	}
+	if ids := strings.Join(found.ReviewerIDs(), ","); ids != jdoe+","+asmith {
+		t.Fatalf("unexpected reviewers: %s", ids)
+	}
+	if url := client.PullRequestURL(fullName, pr.ID); url != server.URL+"/acme/Platform/_git/api/pullrequest/1" {
+		t.Fatalf("unexpected pull request URL: %s", url)
+	}
+}
+
+func TestClient_Unauthorized(t *testing.T) {
+	server := azuredevopstest.NewServer(t)
+	client := azuredevops.NewClient(server.URL, "wrong")
+
+	_, err := client.ListRepositories(t.Context(), "acme", "")
+	var apiErr *azuredevops.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected unauthorized error, got %v", err)
+	}
+}
@@ -0,0 +1,338 @@
+// Package azuredevopstest is an in-memory fake of the Azure DevOps Git REST API. It also serves its
+// repositories over git smart HTTP, so that listing, cloning, pushing and opening pull requests
+// can be exercised end-to-end without network access.
+package azuredevopstest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fredrikaverpil/multipr/internal/azuredevops"
+	"github.com/fredrikaverpil/multipr/internal/gittest"
+)
+
+const (
+	// Token is the only personal access token accepted by the server.
+	Token = "azuredevopstest-token"
+
+	defaultBranch = "main"
+	headsPrefix   = "refs/heads/"
+)
+
+// Server is a fake Azure DevOps instance. Use URL as the base URL of an azuredevops.Client.
+type Server struct {
+	URL string
+
+	t   testing.TB
+	git *gittest.Repos // bare repositories, <org>/<project>/<repo>.git
+
+	mu         sync.Mutex
+	repos      map[string]azuredevops.Repository // by full name
+	identities map[string]string                 // user name -> identity ID
+	prs        map[string][]*azuredevops.PullRequest
+	nextPRID   int
+}
+
+// NewServer starts a fake Azure DevOps instance, which is closed when the test ends.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+
+	s := &Server{
+		t:          t,
+		git:        gittest.New(t),
+		repos:      make(map[string]azuredevops.Repository),
+		identities: make(map[string]string),
+		prs:        make(map[string][]*azuredevops.PullRequest),
+		nextPRID:   1,
+	}
+
+	repo := "/{org}/{project}/_apis/git/repositories/{repo}"
+	pr := repo + "/pullrequests/{id}"
+	api := http.NewServeMux()
+	api.HandleFunc("GET /{org}/_apis/git/repositories", s.listRepositories)
+	api.HandleFunc("GET /{org}/{project}/_apis/git/repositories", s.listRepositories)
+	api.HandleFunc("GET "+repo, s.getRepository)
+	api.HandleFunc("GET "+repo+"/pullrequests", s.listPullRequests)
+	api.HandleFunc("POST "+repo+"/pullrequests", s.createPullRequest)
+	api.HandleFunc("GET "+pr, s.getPullRequest)
+	api.HandleFunc("PATCH "+pr, s.updatePullRequest)
+	api.HandleFunc("PUT "+pr+"/reviewers/{reviewer}", s.addReviewer)
+	api.HandleFunc("GET /{org}/_apis/identities", s.findIdentities)
+
+	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(":"+Token))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Repositories are cloned from <org>/<project>/_git/<repo>
+		if before, after, ok := strings.Cut(r.URL.Path, "/_git/"); ok {
+			name, rest, _ := strings.Cut(after, "/")
+			r.URL.Path = before + "/" + name + ".git/" + rest
+			s.git.ServeHTTP(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != auth {
+			writeError(w, http.StatusUnauthorized, "Access denied")
+			return
+		}
+		if r.URL.Query().Get("api-version") == "" {
+			writeError(w, http.StatusBadRequest, "No api-version was supplied for the request")
+			return
+		}
+		api.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	s.URL = server.URL
+
+	return s
+}
+
+// AddRepository creates a repository of the organization with a single commit on the default branch ("main"),
+// containing the files. The project of the repository defaults to private.
+func (s *Server) AddRepository(organization string, meta azuredevops.Repository, files map[string]string) {
+	s.t.Helper()
+
+	fullName := meta.FullName(organization)
+	if meta.ID == "" {
+		meta.ID = "repo-" + strings.ReplaceAll(fullName, "/", "-")
+	}
+	if meta.Project.Visibility == "" {
+		meta.Project.Visibility = "private"
+	}
+	meta.DefaultBranch = headsPrefix + defaultBranch
+	s.git.Create(fullName+".git", defaultBranch, files)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.repos[fullName] = meta
+}
+
+// AddUser creates a user, which can be requested as a reviewer, and returns its identity ID.
+func (s *Server) AddUser(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := "identity-" + name
+	s.identities[name] = id
+	return id
+}
+
+// PullRequests returns a copy of the pull requests of the repository.
+func (s *Server) PullRequests(fullName string) []azuredevops.PullRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prs := make([]azuredevops.PullRequest, 0, len(s.prs[fullName]))
+	for _, pr := range s.prs[fullName] {
+		prs = append(prs, *pr)
+	}
+	return prs
+}
+
+// BranchFile returns the content of a file on a branch of the repository, e.g. to verify a push.
+func (s *Server) BranchFile(fullName, branch, file string) string {
+	s.t.Helper()
+	return s.git.File(fullName+".git", branch, file)
+}
+
+func (s *Server) listRepositories(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	org, project := r.PathValue("org"), r.PathValue("project")
+	repos := []azuredevops.Repository{}
+	for _, fullName := range slices.Sorted(maps.Keys(s.repos)) {
+		repoOrg, repoProject, _ := azuredevops.SplitFullName(fullName)
+		if repoOrg != org || (project != "" && repoProject != project) {
+			continue
+		}
+		repos = append(repos, s.repos[fullName])
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"count": len(repos), "value": repos})
+}
+
+func (s *Server) getRepository(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	repo, ok := s.repos[fullName(r)]
+	if !ok {
+		writeError(w, http.StatusNotFound, "TF401019: The Git repository with name or identifier "+
+			r.PathValue("repo")+" does not exist or you do not have permissions for the operation you are attempting.")
+		return
+	}
+	writeJSON(w, http.StatusOK, repo)
+}
+
+func (s *Server) listPullRequests(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query := r.URL.Query()
+	prs := []azuredevops.PullRequest{}
+	for _, pr := range s.prs[fullName(r)] {
+		if status := query.Get("searchCriteria.status"); status != "" && status != "all" && pr.Status != status {
+			continue
+		}
+		if source := query.Get("searchCriteria.sourceRefName"); source != "" && pr.SourceRefName != source {
+			continue
+		}
+		prs = append(prs, *pr)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"count": len(prs), "value": prs})
+}
+
+func (s *Server) createPullRequest(w http.ResponseWriter, r *http.Request) {
+	var pr azuredevops.PullRequest
+	if err := json.NewDecoder(r.Body).Decode(&pr); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := fullName(r)
+	if _, ok := s.repos[name]; !ok {
+		writeError(w, http.StatusNotFound, "TF401019: The Git repository does not exist.")
+		return
+	}
+	for _, ref := range []string{pr.SourceRefName, pr.TargetRefName} {
+		if !strings.HasPrefix(ref, headsPrefix) || !s.git.HasBranch(name+".git", strings.TrimPrefix(ref, headsPrefix)) {
+			writeError(w, http.StatusNotFound, "TF401398: The pull request cannot be activated because "+ref+
+				" no longer exists.")
+			return
+		}
+	}
+	for _, reviewer := range pr.Reviewers {
+		if !s.isIdentity(reviewer.ID) {
+			writeError(w, http.StatusBadRequest, "Invalid reviewer: "+reviewer.ID)
+			return
+		}
+	}
+	for _, existing := range s.prs[name] {
+		if existing.Status == "active" && existing.SourceRefName == pr.SourceRefName &&
+			existing.TargetRefName == pr.TargetRefName {
+			writeError(w, http.StatusConflict, "TF401179: An active pull request for the source and target branch "+
+				"already exists.")
+			return
+		}
+	}
+
+	pr.ID = s.nextPRID
+	pr.Status = "active"
+	s.nextPRID++
+	s.prs[name] = append(s.prs[name], &pr)
+
+	writeJSON(w, http.StatusCreated, pr)
+}
+
+func (s *Server) getPullRequest(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pr := s.findPullRequest(r)
+	if pr == nil {
+		writeError(w, http.StatusNotFound, "TF401180: The requested pull request was not found.")
+		return
+	}
+	writeJSON(w, http.StatusOK, pr)
+}
+
+func (s *Server) updatePullRequest(w http.ResponseWriter, r *http.Request) {
+	var update struct {
+		Title       *string `json:"title"`
+		Description *string `json:"description"`
+		IsDraft     *bool   `json:"isDraft"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pr := s.findPullRequest(r)
+	if pr == nil {
+		writeError(w, http.StatusNotFound, "TF401180: The requested pull request was not found.")
+		return
+	}
+	if update.Title != nil {
+		pr.Title = *update.Title
+	}
+	if update.Description != nil {
+		pr.Description = *update.Description
+	}
+	if update.IsDraft != nil {
+		pr.IsDraft = *update.IsDraft
+	}
+	writeJSON(w, http.StatusOK, pr)
+}
+
+func (s *Server) addReviewer(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pr := s.findPullRequest(r)
+	if pr == nil {
+		writeError(w, http.StatusNotFound, "TF401180: The requested pull request was not found.")
+		return
+	}
+	id := r.PathValue("reviewer")
+	if !s.isIdentity(id) {
+		writeError(w, http.StatusBadRequest, "Invalid reviewer: "+id)
+		return
+	}
+
+	reviewer := azuredevops.Reviewer{ID: id}
+	if !slices.Contains(pr.ReviewerIDs(), id) {
+		pr.Reviewers = append(pr.Reviewers, reviewer)
+	}
+	writeJSON(w, http.StatusOK, reviewer)
+}
+
+func (s *Server) findIdentities(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	identities := []azuredevops.Identity{}
+	name := r.URL.Query().Get("filterValue")
+	if id, ok := s.identities[name]; ok {
+		identities = append(identities, azuredevops.Identity{ID: id, ProviderDisplayName: name})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"count": len(identities), "value": identities})
+}
+
+func (s *Server) isIdentity(id string) bool {
+	return slices.Contains(slices.Collect(maps.Values(s.identities)), id)
+}
+
+func (s *Server) findPullRequest(r *http.Request) *azuredevops.PullRequest {
+	id, _ := strconv.Atoi(r.PathValue("id"))
+	for _, pr := range s.prs[fullName(r)] {
+		if pr.ID == id {
+			return pr
+		}
+	}
+	return nil
+}
+
+func fullName(r *http.Request) string {
+	return r.PathValue("org") + "/" + r.PathValue("project") + "/" + r.PathValue("repo")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]any{"message": message, "typeKey": "InvalidArgumentValueException"})
+}
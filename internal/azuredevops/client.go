@@ -0,0 +1,178 @@
+// Package azuredevops is a minimal Azure DevOps (and Azure DevOps Server) Git REST API client.
+//
+// https://learn.microsoft.com/en-us/rest/api/azure/devops/git/
+package azuredevops
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultHost is the host of Azure DevOps Services.
+	DefaultHost = "dev.azure.com"
+
+	apiVersion     = "7.1"
+	requestTimeout = 30 * time.Second
+)
+
+// Client talks to a single Azure DevOps instance. Repositories are named by organization
+// (or collection), project and repository, e.g. "acme/Platform/api".
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a new client, where baseURL is the root of the instance, e.g. "https://dev.azure.com",
+// and token is a personal access token.
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		token:      token,
+		httpClient: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// BaseURL returns the root of the instance on the given host, e.g. "dev.azure.com".
+func BaseURL(host string) string {
+	return "https://" + host
+}
+
+// Token returns the token requests are authenticated with, or an empty string.
+func (c *Client) Token() string {
+	return c.token
+}
+
+// WebURL returns the root of the web interface (and git remotes) of the instance.
+func (c *Client) WebURL() string {
+	return c.baseURL
+}
+
+// CloneURL returns the HTTP clone URL of a repository, which is also the web URL of its files.
+func (c *Client) CloneURL(fullName string) string {
+	org, project, repo := SplitFullName(fullName)
+	return c.baseURL + "/" + url.PathEscape(org) + "/" + url.PathEscape(project) + "/_git/" + url.PathEscape(repo)
+}
+
+// SplitFullName splits a full name into organization, project and repository.
+func SplitFullName(fullName string) (string, string, string) {
+	org, rest, _ := strings.Cut(fullName, "/")
+	project, repo, _ := strings.Cut(rest, "/")
+	return org, project, repo
+}
+
+// identityURL returns the root of the identity API, which Azure DevOps Services serves from another host.
+func (c *Client) identityURL() string {
+	if c.baseURL == BaseURL(DefaultHost) {
+		return BaseURL("vssps." + DefaultHost)
+	}
+	return c.baseURL
+}
+
+// APIError is returned when Azure DevOps responds with a non-2xx status code.
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("azuredevops: %s %s failed with status %d: %s", e.Method, e.Path, e.StatusCode, e.Message)
+}
+
+// IsNotFound reports whether err is an *APIError with status 404.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// repoPath returns the API path of a repository, e.g. "acme/Platform/api".
+func repoPath(fullName string) string {
+	org, project, repo := SplitFullName(fullName)
+	return "/" + url.PathEscape(org) + "/" + url.PathEscape(project) + "/_apis/git/repositories/" + url.PathEscape(repo)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	return c.doURL(ctx, method, c.baseURL, path, body, out)
+}
+
+// doURL sends a request to the path below the root URL, with the API version appended to its query.
+func (c *Client) doURL(ctx context.Context, method, root, path string, body, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request body: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	target := root + path + separator + "api-version=" + apiVersion
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		// Personal access tokens are sent as the password of an empty user name
+		req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(":"+c.token)))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("azuredevops: %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &APIError{
+			Method:     method,
+			Path:       path,
+			StatusCode: resp.StatusCode,
+			Message:    errorMessage(data),
+		}
+	}
+
+	if out != nil && len(data) > 0 {
+		if err = json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// errorMessage returns the message of an Azure DevOps error response, or the raw body.
+func errorMessage(body []byte) string {
+	var response struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil || response.Message == "" {
+		return strings.TrimSpace(string(body))
+	}
+	return response.Message
+}
@@ -0,0 +1,176 @@
+package azuredevops
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const headsPrefix = "refs/heads/"
+
+// PullRequest is a subset of the Azure DevOps pull request resource.
+type PullRequest struct {
+	ID            int        `json:"pullRequestId"`
+	Title         string     `json:"title"`
+	Description   string     `json:"description"`
+	Status        string     `json:"status"` // active, completed or abandoned
+	IsDraft       bool       `json:"isDraft"`
+	SourceRefName string     `json:"sourceRefName"` // e.g. "refs/heads/feature"
+	TargetRefName string     `json:"targetRefName"`
+	Reviewers     []Reviewer `json:"reviewers"`
+}
+
+// ReviewerIDs returns the identity IDs of the reviewers.
+func (pr PullRequest) ReviewerIDs() []string {
+	ids := make([]string, 0, len(pr.Reviewers))
+	for _, r := range pr.Reviewers {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+// Reviewer is a reviewer of a pull request.
+type Reviewer struct {
+	ID         string `json:"id"`
+	UniqueName string `json:"uniqueName,omitempty"` // e.g. "jdoe@example.com"
+}
+
+// PullRequestURL returns the web URL of a pull request, which is not part of the pull request resource.
+func (c *Client) PullRequestURL(fullName string, id int) string {
+	return c.CloneURL(fullName) + "/pullrequest/" + strconv.Itoa(id)
+}
+
+// FindPullRequest returns the active pull request from the branch, or nil if there is none.
+func (c *Client) FindPullRequest(ctx context.Context, fullName, branch string) (*PullRequest, error) {
+	params := url.Values{}
+	params.Set("searchCriteria.status", "active")
+	params.Set("searchCriteria.sourceRefName", headsPrefix+branch)
+
+	var response struct {
+		Value []PullRequest `json:"value"`
+	}
+	path := repoPath(fullName) + "/pullrequests?" + params.Encode()
+	if err := c.do(ctx, http.MethodGet, path, nil, &response); err != nil {
+		return nil, err
+	}
+	for i := range response.Value {
+		if response.Value[i].SourceRefName == headsPrefix+branch {
+			return &response.Value[i], nil
+		}
+	}
+	return nil, nil //nolint:nilnil // no pull request is not an error
+}
+
+// GetPullRequest fetches a single pull request.
+func (c *Client) GetPullRequest(ctx context.Context, fullName string, id int) (*PullRequest, error) {
+	var pr PullRequest
+	path := repoPath(fullName) + "/pullrequests/" + strconv.Itoa(id)
+	if err := c.do(ctx, http.MethodGet, path, nil, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// CreatePullRequestOptions holds the fields for a new pull request between two branches of a repository.
+type CreatePullRequestOptions struct {
+	Title        string
+	Description  string
+	SourceBranch string
+	TargetBranch string
+	ReviewerIDs  []string // identity IDs, see FindIdentity
+	Draft        bool
+}
+
+// CreatePullRequest opens a new pull request.
+func (c *Client) CreatePullRequest(
+	ctx context.Context,
+	fullName string,
+	opts CreatePullRequestOptions,
+) (*PullRequest, error) {
+	reviewers := make([]Reviewer, 0, len(opts.ReviewerIDs))
+	for _, id := range opts.ReviewerIDs {
+		reviewers = append(reviewers, Reviewer{ID: id})
+	}
+
+	body := map[string]any{
+		"title":         opts.Title,
+		"description":   opts.Description,
+		"sourceRefName": headsPrefix + opts.SourceBranch,
+		"targetRefName": headsPrefix + opts.TargetBranch,
+		"reviewers":     reviewers,
+		"isDraft":       opts.Draft,
+	}
+
+	var pr PullRequest
+	if err := c.do(ctx, http.MethodPost, repoPath(fullName)+"/pullrequests", body, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// UpdatePullRequestOptions holds the fields to change of an existing pull request. Nil fields are left as is.
+type UpdatePullRequestOptions struct {
+	Title       *string
+	Description *string
+	Draft       *bool
+}
+
+// UpdatePullRequest edits an existing pull request.
+func (c *Client) UpdatePullRequest(
+	ctx context.Context,
+	fullName string,
+	id int,
+	opts UpdatePullRequestOptions,
+) (*PullRequest, error) {
+	body := map[string]any{}
+	if opts.Title != nil {
+		body["title"] = *opts.Title
+	}
+	if opts.Description != nil {
+		body["description"] = *opts.Description
+	}
+	if opts.Draft != nil {
+		body["isDraft"] = *opts.Draft
+	}
+
+	var pr PullRequest
+	path := repoPath(fullName) + "/pullrequests/" + strconv.Itoa(id)
+	if err := c.do(ctx, http.MethodPatch, path, body, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// AddReviewer requests a review of the pull request from the identity.
+func (c *Client) AddReviewer(ctx context.Context, fullName string, id int, reviewerID string) error {
+	path := repoPath(fullName) + "/pullrequests/" + strconv.Itoa(id) + "/reviewers/" + url.PathEscape(reviewerID)
+	return c.do(ctx, http.MethodPut, path, map[string]any{"vote": 0}, nil)
+}
+
+// Identity is a subset of the Azure DevOps identity resource.
+type Identity struct {
+	ID                  string `json:"id"`
+	ProviderDisplayName string `json:"providerDisplayName"`
+}
+
+// FindIdentity looks up a user of the organization by name or email address, e.g. to request a review.
+func (c *Client) FindIdentity(ctx context.Context, organization, name string) (*Identity, error) {
+	params := url.Values{}
+	params.Set("searchFilter", "General")
+	params.Set("filterValue", name)
+	params.Set("queryMembership", "None")
+
+	var response struct {
+		Value []Identity `json:"value"`
+	}
+	path := "/" + url.PathEscape(organization) + "/_apis/identities?" + params.Encode()
+	if err := c.doURL(ctx, http.MethodGet, c.identityURL(), path, nil, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Value) == 0 {
+		return nil, fmt.Errorf("azuredevops: no identity found for %s in %s", name, organization)
+	}
+	return &response.Value[0], nil
+}
@@ -0,0 +1,56 @@
+package azuredevops
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Repository is a subset of the Azure DevOps Git repository resource.
+type Repository struct {
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`
+	Project       Project `json:"project"`
+	DefaultBranch string  `json:"defaultBranch,omitempty"` // e.g. "refs/heads/main", empty for empty repositories
+	IsDisabled    bool    `json:"isDisabled"`
+	IsFork        bool    `json:"isFork"`
+}
+
+// FullName returns the organization, project and repository name, e.g. "acme/Platform/api".
+// The organization is not part of the repository resource.
+func (r Repository) FullName(organization string) string {
+	return organization + "/" + r.Project.Name + "/" + r.Name
+}
+
+// Project is a subset of the Azure DevOps project resource.
+type Project struct {
+	ID         string `json:"id,omitempty"`
+	Name       string `json:"name"`
+	Visibility string `json:"visibility,omitempty"` // "private" or "public"
+}
+
+// ListRepositories lists the repositories of a project, or of all projects of the organization
+// if the project is empty. The list is not paginated.
+func (c *Client) ListRepositories(ctx context.Context, organization, project string) ([]Repository, error) {
+	path := "/" + url.PathEscape(organization)
+	if project != "" {
+		path += "/" + url.PathEscape(project)
+	}
+
+	var response struct {
+		Value []Repository `json:"value"`
+	}
+	if err := c.do(ctx, http.MethodGet, path+"/_apis/git/repositories", nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Value, nil
+}
+
+// GetRepository fetches a single repository, e.g. "acme/Platform/api".
+func (c *Client) GetRepository(ctx context.Context, fullName string) (*Repository, error) {
+	var r Repository
+	if err := c.do(ctx, http.MethodGet, repoPath(fullName), nil, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
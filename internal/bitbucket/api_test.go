@@ -0,0 +1,133 @@
+package bitbucket_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/fredrikaverpil/multipr/internal/bitbucket"
+	"github.com/fredrikaverpil/multipr/internal/bitbucket/bitbuckettest"
+)
+
+func newTestClient(server *bitbuckettest.Server, token string) *bitbucket.Client {
+	return bitbucket.NewClient(server.URL+"/rest/api/1.0", token)
+}
+
+func TestClient_ListRepositories(t *testing.T) {
+	server := bitbuckettest.NewServer(t)
+	for _, name := range []string{"PLAT/api", "PLAT/web", "LEG/api-v1"} {
+		project, slug, _ := strings.Cut(name, "/")
+		server.AddRepository(bitbucket.Repository{Slug: slug, Project: bitbucket.Project{Key: project}}, nil)
+	}
+	client := newTestClient(server, bitbuckettest.Token)
+
+	tests := []struct {
+		name string
+		opts bitbucket.ListRepositoriesOptions
+		want string
+	}{
+		{name: "all", want: "LEG/api-v1,PLAT/api,PLAT/web"},
+		{name: "project", opts: bitbucket.ListRepositoriesOptions{Project: "PLAT"}, want: "PLAT/api,PLAT/web"},
+		{name: "name", opts: bitbucket.ListRepositoriesOptions{Name: "api"}, want: "LEG/api-v1,PLAT/api"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos, err := client.ListRepositories(t.Context(), tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range repos {
+				got = append(got, r.FullName())
+			}
+			if strings.Join(got, ",") != tt.want {
+				t.Fatalf("got %v, want %s", got, tt.want)
+			}
+		})
+	}
+
+	branch, err := client.DefaultBranch(t.Context(), "PLAT/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if branch.DisplayID != "main" {
+		t.Fatalf("expected default branch main, got %+v", branch)
+	}
+
+	if _, err = client.GetRepository(t.Context(), "PLAT/missing"); !bitbucket.IsNotFound(err) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestClient_PullRequestLifecycle(t *testing.T) {
+	server := bitbuckettest.NewServer(t)
+	server.AddRepository(bitbucket.Repository{Slug: "api", Project: bitbucket.Project{Key: "PLAT"}}, map[string]string{
+		"README.md": "# api\n",
+	})
+	server.AddUser("jdoe")
+	client := newTestClient(server, bitbuckettest.Token)
+	ctx := t.Context()
+
+	pr, err := client.FindPullRequest(ctx, "PLAT/api", "main")
+	if err != nil || pr != nil {
+		t.Fatalf("expected no pull request, got %+v, %v", pr, err)
+	}
+
+	// Reviewers must exist
+	_, err = client.CreatePullRequest(ctx, "PLAT/api", bitbucket.CreatePullRequestOptions{
+		Title: "chore: update", FromBranch: "main", ToBranch: "main", Reviewers: []string{"nobody"},
+	})
+	var apiErr *bitbucket.APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "User nobody does not exist." {
+		t.Fatalf("expected conflict for an unknown reviewer, got %v", err)
+	}
+
+	pr, err = client.CreatePullRequest(ctx, "PLAT/api", bitbucket.CreatePullRequestOptions{
+		Title: "chore: update", Description: "body", FromBranch: "main", ToBranch: "main",
+		Reviewers: []string{"jdoe"}, Draft: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Updates with an outdated version are rejected
+	_, err = client.UpdatePullRequest(ctx, "PLAT/api", pr.ID, bitbucket.UpdatePullRequestOptions{Version: 1})
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Fatalf("expected conflict for an outdated version, got %v", err)
+	}
+
+	if _, err = client.UpdatePullRequest(ctx, "PLAT/api", pr.ID, bitbucket.UpdatePullRequestOptions{
+		Version:     pr.Version,
+		Title:       "chore: updated",
+		Description: pr.Description,
+		Reviewers:   pr.ReviewerNames(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := client.FindPullRequest(ctx, "PLAT/api", "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.ID != pr.ID || found.Title != "chore: updated" || found.Description != "body" || found.Draft {
+		t.Fatalf("unexpected pull request: %+v", found)
+	}
+	if reviewers := found.ReviewerNames(); len(reviewers) != 1 || reviewers[0] != "jdoe" {
+		t.Fatalf("expected jdoe to be a reviewer, got %v", reviewers)
+	}
+	if !strings.HasSuffix(found.URL(), "/projects/PLAT/repos/api/pull-requests/1") {
+		t.Fatalf("unexpected pull request URL: %s", found.URL())
+	}
+}
+
+func TestClient_Unauthorized(t *testing.T) {
+	server := bitbuckettest.NewServer(t)
+	client := newTestClient(server, "wrong")
+
+	_, err := client.ListRepositories(t.Context(), bitbucket.ListRepositoriesOptions{})
+	var apiErr *bitbucket.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected unauthorized error, got %v", err)
+	}
+}
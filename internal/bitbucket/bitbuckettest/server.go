@@ -0,0 +1,339 @@
+// Package bitbuckettest is an in-memory fake of the Bitbucket Server REST API. It also serves its
+// repositories over git smart HTTP, so that listing, cloning, pushing and opening pull requests
+// can be exercised end-to-end without network access.
+package bitbuckettest
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fredrikaverpil/multipr/internal/bitbucket"
+	"github.com/fredrikaverpil/multipr/internal/gittest"
+)
+
+const (
+	// Token is the only token accepted by the server.
+	Token = "bitbuckettest-token"
+
+	defaultBranch = "main"
+	defaultLimit  = 25
+	apiPrefix     = "/rest/api/1.0"
+)
+
+// Server is a fake Bitbucket Server instance. Use URL+"/rest/api/1.0" as the base URL of a bitbucket.Client.
+type Server struct {
+	URL string
+
+	t   testing.TB
+	git *gittest.Repos // bare repositories, scm/<project>/<repo>.git
+
+	mu       sync.Mutex
+	repos    map[string]repository
+	users    map[string]bool // users which can be added as reviewers
+	prs      map[string][]*bitbucket.PullRequest
+	nextPRID int
+}
+
+type repository struct {
+	meta          bitbucket.Repository
+	defaultBranch string
+}
+
+// NewServer starts a fake Bitbucket Server instance, which is closed when the test ends.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+
+	s := &Server{
+		t:        t,
+		git:      gittest.New(t),
+		repos:    make(map[string]repository),
+		users:    make(map[string]bool),
+		prs:      make(map[string][]*bitbucket.PullRequest),
+		nextPRID: 1,
+	}
+
+	repo := apiPrefix + "/projects/{project}/repos/{slug}"
+	api := http.NewServeMux()
+	api.HandleFunc("GET "+apiPrefix+"/repos", s.listRepositories)
+	api.HandleFunc("GET "+apiPrefix+"/projects/{project}/repos", s.listRepositories)
+	api.HandleFunc("GET "+repo, s.getRepository)
+	api.HandleFunc("GET "+repo+"/branches/default", s.getDefaultBranch)
+	api.HandleFunc("GET "+repo+"/pull-requests", s.listPullRequests)
+	api.HandleFunc("POST "+repo+"/pull-requests", s.createPullRequest)
+	api.HandleFunc("GET "+repo+"/pull-requests/{id}", s.getPullRequest)
+	api.HandleFunc("PUT "+repo+"/pull-requests/{id}", s.updatePullRequest)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if gittest.IsGitRequest(r) {
+			s.git.ServeHTTP(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer "+Token {
+			writeError(w, http.StatusUnauthorized, "Authentication failed")
+			return
+		}
+		api.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	s.URL = server.URL
+
+	return s
+}
+
+// AddRepository creates a repository with a single commit on the default branch ("main"), containing the files.
+// The repository's name defaults to its slug.
+func (s *Server) AddRepository(meta bitbucket.Repository, files map[string]string) {
+	s.t.Helper()
+
+	if meta.Name == "" {
+		meta.Name = meta.Slug
+	}
+	s.git.Create("scm/"+meta.FullName()+".git", defaultBranch, files)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.repos[meta.FullName()] = repository{meta: meta, defaultBranch: defaultBranch}
+}
+
+// AddUser creates a user, which can be added as a reviewer.
+func (s *Server) AddUser(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[name] = true
+}
+
+// PullRequests returns a copy of the pull requests of the repository.
+func (s *Server) PullRequests(fullName string) []bitbucket.PullRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prs := make([]bitbucket.PullRequest, 0, len(s.prs[fullName]))
+	for _, pr := range s.prs[fullName] {
+		prs = append(prs, *pr)
+	}
+	return prs
+}
+
+// BranchFile returns the content of a file on a branch of the repository, e.g. to verify a push.
+func (s *Server) BranchFile(fullName, branch, file string) string {
+	s.t.Helper()
+	return s.git.File("scm/"+fullName+".git", branch, file)
+}
+
+func (s *Server) listRepositories(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project := r.PathValue("project")
+	name := strings.ToLower(r.URL.Query().Get("name"))
+
+	var repos []bitbucket.Repository
+	for _, fullName := range slices.Sorted(maps.Keys(s.repos)) {
+		meta := s.repos[fullName].meta
+		if project != "" && meta.Project.Key != project {
+			continue
+		}
+		if !strings.Contains(strings.ToLower(meta.Name), name) {
+			continue
+		}
+		repos = append(repos, meta)
+	}
+	writePage(w, r, repos)
+}
+
+func (s *Server) getRepository(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	repo, ok := s.repos[fullName(r)]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Repository "+fullName(r)+" does not exist.")
+		return
+	}
+	writeJSON(w, http.StatusOK, repo.meta)
+}
+
+func (s *Server) getDefaultBranch(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	repo, ok := s.repos[fullName(r)]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Repository "+fullName(r)+" does not exist.")
+		return
+	}
+	writeJSON(w, http.StatusOK, bitbucket.Branch{ID: "refs/heads/" + repo.defaultBranch, DisplayID: repo.defaultBranch})
+}
+
+func (s *Server) listPullRequests(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query := r.URL.Query()
+	var prs []bitbucket.PullRequest
+	for _, pr := range s.prs[fullName(r)] {
+		if state := query.Get("state"); state != "" && state != "ALL" && pr.State != state {
+			continue
+		}
+		if at := query.Get("at"); at != "" && pr.FromRef.ID != at {
+			continue
+		}
+		prs = append(prs, *pr)
+	}
+	writePage(w, r, prs)
+}
+
+func (s *Server) createPullRequest(w http.ResponseWriter, r *http.Request) {
+	var pr bitbucket.PullRequest
+	if err := json.NewDecoder(r.Body).Decode(&pr); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := fullName(r)
+	if _, ok := s.repos[name]; !ok {
+		writeError(w, http.StatusNotFound, "Repository "+name+" does not exist.")
+		return
+	}
+	if pr.FromRef.Repository == nil || pr.FromRef.Repository.FullName() != name {
+		writeError(w, http.StatusBadRequest, "fromRef.repository is required")
+		return
+	}
+	for _, ref := range []string{pr.FromRef.ID, pr.ToRef.ID} {
+		if !s.git.HasBranch("scm/"+name+".git", strings.TrimPrefix(ref, "refs/heads/")) {
+			writeError(w, http.StatusNotFound, "Repository "+name+" has no branch "+ref)
+			return
+		}
+	}
+	if msg := s.validateReviewers(pr.Reviewers); msg != "" {
+		writeError(w, http.StatusConflict, msg)
+		return
+	}
+	for _, existing := range s.prs[name] {
+		if existing.State == "OPEN" && existing.FromRef.ID == pr.FromRef.ID {
+			writeError(w, http.StatusConflict, "Only one pull request may be open for a given source and target branch")
+			return
+		}
+	}
+
+	pr.ID = s.nextPRID
+	pr.Version = 0
+	pr.State = "OPEN"
+	pr.Links.Self = []bitbucket.Link{{Href: fmt.Sprintf("%s/projects/%s/repos/%s/pull-requests/%d",
+		s.URL, r.PathValue("project"), r.PathValue("slug"), pr.ID)}}
+	s.nextPRID++
+	s.prs[name] = append(s.prs[name], &pr)
+
+	writeJSON(w, http.StatusCreated, pr)
+}
+
+func (s *Server) getPullRequest(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pr := s.findPullRequest(r)
+	if pr == nil {
+		writeError(w, http.StatusNotFound, "Pull request does not exist.")
+		return
+	}
+	writeJSON(w, http.StatusOK, pr)
+}
+
+func (s *Server) updatePullRequest(w http.ResponseWriter, r *http.Request) {
+	var update bitbucket.PullRequest
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pr := s.findPullRequest(r)
+	if pr == nil {
+		writeError(w, http.StatusNotFound, "Pull request does not exist.")
+		return
+	}
+	if update.Version != pr.Version {
+		writeError(w, http.StatusConflict, "The pull request has been updated since it was last fetched")
+		return
+	}
+	if msg := s.validateReviewers(update.Reviewers); msg != "" {
+		writeError(w, http.StatusConflict, msg)
+		return
+	}
+
+	pr.Version++
+	pr.Title = update.Title
+	pr.Description = update.Description
+	pr.Reviewers = update.Reviewers
+	pr.Draft = update.Draft
+	writeJSON(w, http.StatusOK, pr)
+}
+
+func (s *Server) validateReviewers(reviewers []bitbucket.Participant) string {
+	for _, reviewer := range reviewers {
+		if !s.users[reviewer.User.Name] {
+			return "User " + reviewer.User.Name + " does not exist."
+		}
+	}
+	return ""
+}
+
+func (s *Server) findPullRequest(r *http.Request) *bitbucket.PullRequest {
+	id, _ := strconv.Atoi(r.PathValue("id"))
+	for _, pr := range s.prs[fullName(r)] {
+		if pr.ID == id {
+			return pr
+		}
+	}
+	return nil
+}
+
+func fullName(r *http.Request) string {
+	return r.PathValue("project") + "/" + r.PathValue("slug")
+}
+
+// writePage writes the page of values requested with the start and limit query parameters.
+func writePage[T any](w http.ResponseWriter, r *http.Request, values []T) {
+	start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+
+	start = min(max(start, 0), len(values))
+	end := min(start+limit, len(values))
+	page := map[string]any{
+		"size":       end - start,
+		"limit":      limit,
+		"start":      start,
+		"isLastPage": end == len(values),
+		"values":     append([]T{}, values[start:end]...),
+	}
+	if end < len(values) {
+		page["nextPageStart"] = end
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]any{"errors": []map[string]string{{"message": message}}})
+}
@@ -0,0 +1,180 @@
+// Package bitbucket is a minimal Bitbucket Server (and Data Center) REST API client.
+//
+// https://developer.atlassian.com/server/bitbucket/rest/
+package bitbucket
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	perPage        = 100
+	requestTimeout = 30 * time.Second
+	apiPath        = "/rest/api/1.0"
+)
+
+// Client talks to a single Bitbucket Server instance.
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a new client, where baseURL is the API root,
+// e.g. "https://bitbucket.example.com/rest/api/1.0".
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		token:      token,
+		httpClient: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// BaseURL returns the API root for the given host, e.g. "bitbucket.example.com".
+func BaseURL(host string) string {
+	return "https://" + host + apiPath
+}
+
+// Token returns the token requests are authenticated with, or an empty string.
+func (c *Client) Token() string {
+	return c.token
+}
+
+// WebURL returns the root of the web interface of the instance.
+func (c *Client) WebURL() string {
+	return strings.TrimSuffix(c.baseURL, apiPath)
+}
+
+// CloneURL returns the HTTP clone URL of a repository, e.g. "PROJ/repo".
+func (c *Client) CloneURL(fullName string) string {
+	return c.WebURL() + "/scm/" + fullName + ".git"
+}
+
+// APIError is returned when Bitbucket responds with a non-2xx status code.
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("bitbucket: %s %s failed with status %d: %s", e.Method, e.Path, e.StatusCode, e.Message)
+}
+
+// IsNotFound reports whether err is an *APIError with status 404.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// repoPath returns the API path of a repository, e.g. "PROJ/repo".
+func repoPath(fullName string) string {
+	project, slug, _ := strings.Cut(fullName, "/")
+	return "/projects/" + url.PathEscape(project) + "/repos/" + url.PathEscape(slug)
+}
+
+// getPaged fetches all pages of a paged API resource.
+func getPaged[T any](ctx context.Context, c *Client, path string, params url.Values) ([]T, error) {
+	params.Set("limit", strconv.Itoa(perPage))
+
+	var values []T
+	start := 0
+	for {
+		params.Set("start", strconv.Itoa(start))
+
+		var page struct {
+			Values        []T  `json:"values"`
+			IsLastPage    bool `json:"isLastPage"`
+			NextPageStart int  `json:"nextPageStart"`
+		}
+		if err := c.do(ctx, http.MethodGet, path+"?"+params.Encode(), nil, &page); err != nil {
+			return nil, err
+		}
+		values = append(values, page.Values...)
+
+		if page.IsLastPage || len(page.Values) == 0 {
+			return values, nil
+		}
+		start = page.NextPageStart
+	}
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request body: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("bitbucket: %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &APIError{
+			Method:     method,
+			Path:       path,
+			StatusCode: resp.StatusCode,
+			Message:    errorMessage(data),
+		}
+	}
+
+	if out != nil && len(data) > 0 {
+		if err = json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// errorMessage returns the messages of a Bitbucket error response, or the raw body.
+func errorMessage(body []byte) string {
+	var response struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil || len(response.Errors) == 0 {
+		return strings.TrimSpace(string(body))
+	}
+
+	messages := make([]string, 0, len(response.Errors))
+	for _, e := range response.Errors {
+		messages = append(messages, e.Message)
+	}
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,174 @@
+package bitbucket
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const headsPrefix = "refs/heads/"
+
+// PullRequest is a subset of the Bitbucket pull request resource.
+type PullRequest struct {
+	ID          int    `json:"id"`
+	Version     int    `json:"version"` // required to update the pull request
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	State       string `json:"state"` // OPEN, MERGED or DECLINED
+	// Draft is only supported by Bitbucket Data Center 8.18 and later
+	Draft     bool          `json:"draft"`
+	FromRef   Ref           `json:"fromRef"`
+	ToRef     Ref           `json:"toRef"`
+	Reviewers []Participant `json:"reviewers"`
+	Links     struct {
+		Self []Link `json:"self"`
+	} `json:"links"`
+}
+
+// URL returns the web URL of the pull request.
+func (pr PullRequest) URL() string {
+	if len(pr.Links.Self) == 0 {
+		return ""
+	}
+	return pr.Links.Self[0].Href
+}
+
+// ReviewerNames returns the user names of the reviewers.
+func (pr PullRequest) ReviewerNames() []string {
+	names := make([]string, 0, len(pr.Reviewers))
+	for _, r := range pr.Reviewers {
+		names = append(names, r.User.Name)
+	}
+	return names
+}
+
+// Ref is a branch of a repository.
+type Ref struct {
+	ID         string      `json:"id"` // e.g. "refs/heads/main"
+	DisplayID  string      `json:"displayId,omitempty"`
+	Repository *Repository `json:"repository,omitempty"`
+}
+
+// Participant is a reviewer of a pull request.
+type Participant struct {
+	User User `json:"user"`
+}
+
+// User is a subset of the Bitbucket user resource.
+type User struct {
+	Name string `json:"name"`
+}
+
+// Link is a hyperlink of a resource.
+type Link struct {
+	Href string `json:"href"`
+}
+
+// FindPullRequest returns the open pull request from the branch of the repository itself,
+// or nil if there is none.
+func (c *Client) FindPullRequest(ctx context.Context, fullName, branch string) (*PullRequest, error) {
+	params := url.Values{}
+	params.Set("state", "OPEN")
+	params.Set("direction", "OUTGOING")
+	params.Set("at", headsPrefix+branch)
+
+	prs, err := getPaged[PullRequest](ctx, c, repoPath(fullName)+"/pull-requests", params)
+	if err != nil {
+		return nil, err
+	}
+	for i := range prs {
+		from := prs[i].FromRef
+		if from.ID == headsPrefix+branch && from.Repository != nil && from.Repository.FullName() == fullName {
+			return &prs[i], nil
+		}
+	}
+	return nil, nil //nolint:nilnil // no pull request is not an error
+}
+
+// GetPullRequest fetches a single pull request.
+func (c *Client) GetPullRequest(ctx context.Context, fullName string, id int) (*PullRequest, error) {
+	var pr PullRequest
+	path := repoPath(fullName) + "/pull-requests/" + strconv.Itoa(id)
+	if err := c.do(ctx, http.MethodGet, path, nil, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// CreatePullRequestOptions holds the fields for a new pull request between two branches of a repository.
+type CreatePullRequestOptions struct {
+	Title       string
+	Description string
+	FromBranch  string
+	ToBranch    string
+	Reviewers   []string // user names
+	Draft       bool
+}
+
+// CreatePullRequest opens a new pull request.
+func (c *Client) CreatePullRequest(
+	ctx context.Context,
+	fullName string,
+	opts CreatePullRequestOptions,
+) (*PullRequest, error) {
+	project, slug, _ := strings.Cut(fullName, "/")
+	repo := &Repository{Slug: slug, Project: Project{Key: project}}
+
+	body := map[string]any{
+		"title":       opts.Title,
+		"description": opts.Description,
+		"fromRef":     Ref{ID: headsPrefix + opts.FromBranch, Repository: repo},
+		"toRef":       Ref{ID: headsPrefix + opts.ToBranch, Repository: repo},
+		"reviewers":   participants(opts.Reviewers),
+		"draft":       opts.Draft,
+	}
+
+	var pr PullRequest
+	if err := c.do(ctx, http.MethodPost, repoPath(fullName)+"/pull-requests", body, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// UpdatePullRequestOptions holds the complete state of an existing pull request.
+// Bitbucket replaces the reviewers, so unchanged reviewers must be included.
+type UpdatePullRequestOptions struct {
+	Version     int
+	Title       string
+	Description string
+	Reviewers   []string // user names
+	Draft       bool
+}
+
+// UpdatePullRequest edits an existing pull request. The update is rejected if the version is outdated.
+func (c *Client) UpdatePullRequest(
+	ctx context.Context,
+	fullName string,
+	id int,
+	opts UpdatePullRequestOptions,
+) (*PullRequest, error) {
+	body := map[string]any{
+		"version":     opts.Version,
+		"title":       opts.Title,
+		"description": opts.Description,
+		"reviewers":   participants(opts.Reviewers),
+		"draft":       opts.Draft,
+	}
+
+	var pr PullRequest
+	path := repoPath(fullName) + "/pull-requests/" + strconv.Itoa(id)
+	if err := c.do(ctx, http.MethodPut, path, body, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+func participants(names []string) []Participant {
+	reviewers := make([]Participant, 0, len(names))
+	for _, name := range names {
+		reviewers = append(reviewers, Participant{User: User{Name: name}})
+	}
+	return reviewers
+}
@@ -0,0 +1,71 @@
+package bitbucket
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Repository is a subset of the Bitbucket repository resource.
+type Repository struct {
+	Slug    string  `json:"slug"`
+	Name    string  `json:"name"`
+	Project Project `json:"project"`
+	Public  bool    `json:"public"`
+	// Archived is only reported by Bitbucket Data Center 8.0 and later
+	Archived bool        `json:"archived"`
+	Origin   *Repository `json:"origin,omitempty"` // set for forks
+}
+
+// FullName returns the project key and repository slug, e.g. "PROJ/repo".
+func (r Repository) FullName() string {
+	return r.Project.Key + "/" + r.Slug
+}
+
+// Project is a subset of the Bitbucket project resource.
+type Project struct {
+	Key string `json:"key"`
+}
+
+// Branch is a subset of the Bitbucket branch resource.
+type Branch struct {
+	ID        string `json:"id"`        // e.g. "refs/heads/main"
+	DisplayID string `json:"displayId"` // e.g. "main"
+}
+
+// ListRepositoriesOptions filters the repositories returned by ListRepositories.
+type ListRepositoriesOptions struct {
+	Project string // project key, lists the repositories of the project
+	Name    string // matched against the repository name, ignored when listing a project
+}
+
+// ListRepositories lists all repositories of a project, or else all repositories accessible to the user,
+// following pagination.
+func (c *Client) ListRepositories(ctx context.Context, opts ListRepositoriesOptions) ([]Repository, error) {
+	params := url.Values{}
+	if opts.Project != "" {
+		return getPaged[Repository](ctx, c, "/projects/"+url.PathEscape(opts.Project)+"/repos", params)
+	}
+	if opts.Name != "" {
+		params.Set("name", opts.Name)
+	}
+	return getPaged[Repository](ctx, c, "/repos", params)
+}
+
+// GetRepository fetches a single repository, e.g. "PROJ/repo".
+func (c *Client) GetRepository(ctx context.Context, fullName string) (*Repository, error) {
+	var r Repository
+	if err := c.do(ctx, http.MethodGet, repoPath(fullName), nil, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// DefaultBranch fetches the default branch of a repository, which is not part of the repository resource.
+func (c *Client) DefaultBranch(ctx context.Context, fullName string) (*Branch, error) {
+	var b Branch
+	if err := c.do(ctx, http.MethodGet, repoPath(fullName)+"/branches/default", nil, &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
@@ -54,7 +54,13 @@ func (e *Executor) execute(cmd *exec.Cmd, opts ...Option) (*Result, error) {
 	if options.dir != "" {
 		cmd.Dir = options.dir
 	}
-	multiWriter := options.tee || e.debug
+	if options.stdin != nil {
+		cmd.Stdin = options.stdin
+	}
+	if len(options.env) > 0 {
+		cmd.Env = append(os.Environ(), options.env...)
+	}
+	multiWriter := (options.tee || e.debug) && !options.secret
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
@@ -5,22 +5,36 @@ import (
 	"fmt"
 )
 
-func (e *Executor) GitClone(ctx context.Context, repo, path string) error {
-	_, err := e.Execute(ctx, "git", []string{"clone", repo, path})
+// GitClone clones the repository into path, passing the flags (e.g. "--depth=1") to git clone.
+func (e *Executor) GitClone(ctx context.Context, repo, path string, flags []string, opts ...Option) error {
+	args := append(append([]string{"clone"}, flags...), repo, path)
+	_, err := e.Execute(ctx, "git", args, opts...)
 	if err != nil {
 		return fmt.Errorf("failed to clone repository: %w", err)
 	}
 	return nil
 }
 
-func (e *Executor) GitFetchAll(ctx context.Context, dir string) error {
-	_, err := e.Execute(ctx, "git", []string{"fetch", "--all"}, WithDir(dir))
+// GitFetchAll fetches all remotes, passing the flags (e.g. "--depth=1") to git fetch.
+func (e *Executor) GitFetchAll(ctx context.Context, dir string, flags []string, opts ...Option) error {
+	args := append([]string{"fetch", "--all"}, flags...)
+	_, err := e.Execute(ctx, "git", args, append(opts, WithDir(dir))...)
 	if err != nil {
 		return fmt.Errorf("failed to fetch all: %w", err)
 	}
 	return nil
 }
 
+// GitSparseCheckout limits the working tree to the paths matching the patterns.
+func (e *Executor) GitSparseCheckout(ctx context.Context, dir string, patterns []string) error {
+	args := append([]string{"sparse-checkout", "set", "--no-cone"}, patterns...)
+	_, err := e.Execute(ctx, "git", args, WithDir(dir))
+	if err != nil {
+		return fmt.Errorf("failed to set sparse-checkout patterns: %w", err)
+	}
+	return nil
+}
+
 func (e *Executor) GitCheckout(ctx context.Context, dir, branch string) error {
 	_, err := e.Execute(ctx, "git", []string{"checkout", "-B", branch}, WithDir(dir))
 	if err != nil {
@@ -52,8 +66,9 @@ func (e *Executor) GitDiff(ctx context.Context, dir string) error {
 	return nil
 }
 
-func (e *Executor) GitPushForce(ctx context.Context, dir, branch string) error {
-	_, err := e.Execute(ctx, "git", []string{"push", "-u", "origin", branch, "--force-with-lease"}, WithDir(dir))
+func (e *Executor) GitPushForce(ctx context.Context, dir, branch string, opts ...Option) error {
+	args := []string{"push", "-u", "origin", branch, "--force-with-lease"}
+	_, err := e.Execute(ctx, "git", args, append(opts, WithDir(dir))...)
 	if err != nil {
 		return fmt.Errorf("failed to push branch: %w", err)
 	}
@@ -1,10 +1,15 @@
 package command
 
+import "io"
+
 type Option func(*execOptions)
 
 type execOptions struct {
-	dir string
-	tee bool
+	dir    string
+	tee    bool
+	secret bool
+	stdin  io.Reader
+	env    []string
 }
 
 // WithDir sets the working directory for the command.
@@ -20,3 +25,24 @@ func WithTee() Option {
 		o.tee = true
 	}
 }
+
+// WithSecretOutput never duplicates the command's output, not even in debug mode, e.g. as it prints a token.
+func WithSecretOutput() Option {
+	return func(o *execOptions) {
+		o.secret = true
+	}
+}
+
+// WithStdin passes the reader to the command's stdin.
+func WithStdin(r io.Reader) Option {
+	return func(o *execOptions) {
+		o.stdin = r
+	}
+}
+
+// WithEnv adds the "KEY=value" pairs to the environment of the command.
+func WithEnv(env ...string) Option {
+	return func(o *execOptions) {
+		o.env = append(o.env, env...)
+	}
+}
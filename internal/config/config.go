@@ -2,32 +2,330 @@ package config
 
 import (
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
+const (
+	DefaultGitHubHost      = "github.com"
+	DefaultGitLabHost      = "gitlab.com"
+	DefaultAzureDevOpsHost = "dev.azure.com"
+)
+
 // JobConfig represents the YAML job configuration.
 type JobConfig struct {
 	Name string `yaml:"name"`
 
-	Search struct {
-		GitHub struct {
-			Method string `yaml:"method"`
-			Query  string `yaml:"query"`
-		} `yaml:"github"`
-	} `yaml:"search"`
+	Search Search `yaml:"search"`
 
 	Identify []Command `yaml:"identify"`
 
 	Changes []Command `yaml:"changes"`
 
-	PR struct {
-		GitHub struct {
-			Title  string `yaml:"title"`
-			Body   string `yaml:"body"`
-			Branch string `yaml:"branch"`
-		} `yaml:"github"`
-	} `yaml:"pr"`
+	PR PullRequests `yaml:"pr"`
+
+	Publish Publish `yaml:"publish,omitempty"`
+
+	Clone Clone `yaml:"clone,omitempty"`
+
+	Hosts []Host `yaml:"hosts,omitempty"`
+}
+
+// Host configures the credentials and environment used for a host, e.g. to target several GitHub
+// Enterprise Server instances with different tokens in one job. Hosts which are not listed use the
+// default environment variables of their provider.
+type Host struct {
+	Host string `yaml:"host"`
+	// Kind is github, gitlab, gitea, bitbucket or azuredevops, and can be omitted for hosts which are searched
+	Kind string `yaml:"kind,omitempty"`
+	// TokenEnv is the environment variable holding the API token, e.g. "GHE_TOKEN"
+	TokenEnv string `yaml:"token_env,omitempty"`
+	// Env is set for git commands against the host, e.g. GIT_SSH_COMMAND or GIT_CONFIG_GLOBAL
+	Env map[string]string `yaml:"env,omitempty"`
+	// PR overrides the pr block for the repositories of the host
+	PR *PullRequest `yaml:"pr,omitempty"`
+}
+
+// Host returns the configuration of the host, if it is listed in hosts.
+func (c *JobConfig) Host(host string) (Host, bool) {
+	for _, h := range c.Hosts {
+		if h.Host == host {
+			return h, true
+		}
+	}
+	return Host{}, false
+}
+
+const (
+	PublishModePR     = "pr"
+	PublishModePush   = "push"
+	PublishModeGerrit = "gerrit"
+	PublishModeEmail  = "email"
+)
+
+// Publish holds the publishing configuration.
+type Publish struct {
+	// Mode is pr (default), which pushes the branch and opens or updates a pull request,
+	// push, which only pushes the branch and records its compare URL,
+	// gerrit, which uploads the commit as a change to refs/for/<default branch>,
+	// or email, which formats the commits as a patch series
+	Mode string `yaml:"mode,omitempty"`
+
+	Email Email `yaml:"email,omitempty"`
+}
+
+// Email configures the patch series of the email publish mode, whose cover letter holds the PR title and body.
+type Email struct {
+	To            []string `yaml:"to,omitempty"`
+	Cc            []string `yaml:"cc,omitempty"`
+	SubjectPrefix string   `yaml:"subject_prefix,omitempty"` // defaults to "PATCH"
+	// Sendmail is a shell command which is passed each message on stdin, e.g. "msmtp -t".
+	// If it is empty, the patches are only written to the job work dir.
+	Sendmail string `yaml:"sendmail,omitempty"`
+}
+
+// ModeOrDefault returns the configured publish mode, or pr.
+func (p Publish) ModeOrDefault() string {
+	if p.Mode == "" {
+		return PublishModePR
+	}
+	return p.Mode
+}
+
+// Clone limits what is downloaded of each repository, e.g. to update a single file of a large monorepo.
+// The zero value makes full clones.
+type Clone struct {
+	// Depth makes shallow clones of the last commits, and fetches are kept at the same depth
+	Depth int `yaml:"depth,omitempty"`
+	// Filter makes partial clones with git's object filter, e.g. "blob:none"
+	Filter string `yaml:"filter,omitempty"`
+	// Sparse checks out only the paths matching the patterns (in .gitignore syntax), e.g. ".github/"
+	Sparse []string `yaml:"sparse,omitempty"`
+	// Mirror borrows objects from bare mirrors in the user's cache directory, which are shared by all jobs
+	Mirror bool `yaml:"mirror,omitempty"`
+	// FetchTTL skips fetching clones which were fetched more recently, e.g. "15m". By default, clones are
+	// fetched every time the default branch is checked out.
+	FetchTTL time.Duration `yaml:"fetch_ttl,omitempty"`
+}
+
+const (
+	CombineUnion     = "union"
+	CombineIntersect = "intersect"
+	CombineSubtract  = "subtract"
+)
+
+// Search holds the search configuration. Besides the search configured directly in the block,
+// any number of named sources can be listed, whose results are combined in order.
+// The search block can also be written as just a list of sources.
+type Search struct {
+	SearchSource `yaml:",inline"`
+
+	Sources []SearchSource `yaml:"sources,omitempty"`
+
+	Filters Filters `yaml:"filters,omitempty"`
+}
+
+// SearchSource holds the search configuration of each hosting provider,
+// and static lists of repositories which are added to the search results.
+type SearchSource struct {
+	Name string `yaml:"name,omitempty"`
+	// Combine is union (default), intersect or subtract, with the results of previous sources
+	Combine string `yaml:"combine,omitempty"`
+
+	GitHub      GitHubSearch      `yaml:"github,omitempty"`
+	GitLab      GitLabSearch      `yaml:"gitlab,omitempty"`
+	Gitea       GiteaSearch       `yaml:"gitea,omitempty"`
+	Bitbucket   BitbucketSearch   `yaml:"bitbucket,omitempty"`
+	AzureDevOps AzureDevOpsSearch `yaml:"azure_devops,omitempty"`
+	Local       LocalSearch       `yaml:"local,omitempty"`
+
+	Repos     []string `yaml:"repos,omitempty"`      // e.g. "github.com/owner/repo"
+	ReposFile string   `yaml:"repos_file,omitempty"` // newline separated or CSV, relative to the job file
+}
+
+// UnmarshalYAML accepts both a mapping and a list of sources.
+func (s *Search) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.SequenceNode {
+		return node.Decode(&s.Sources)
+	}
+
+	type plain Search // avoid recursing into this method
+	return node.Decode((*plain)(s))
+}
+
+// All returns the sources to search, in order, starting with the search configured directly in the block.
+func (s Search) All() []SearchSource {
+	var sources []SearchSource
+	if !s.SearchSource.IsEmpty() {
+		sources = append(sources, s.SearchSource)
+	}
+	return append(sources, s.Sources...)
+}
+
+// IsEmpty returns true if the source does not search or list any repositories.
+func (s SearchSource) IsEmpty() bool {
+	return s.GitHub.Method == "" && !s.GitLab.Enabled() && !s.Gitea.Enabled() && !s.Bitbucket.Enabled() &&
+		!s.AzureDevOps.Enabled() && !s.Local.Enabled() && len(s.Repos) == 0 && s.ReposFile == ""
+}
+
+// Filters narrow down the search results before they are cloned.
+// Unset fields do not filter anything.
+type Filters struct {
+	// Include and Exclude match repository names (e.g. "owner/repo" or "github.com/owner/repo")
+	// against globs, or regular expressions when wrapped in slashes, e.g. "/^acme\/svc-.*$/".
+	Include []string `yaml:"include,omitempty"`
+	Exclude []string `yaml:"exclude,omitempty"`
+
+	Archived   *bool    `yaml:"archived,omitempty"`   // false excludes archived repositories, true keeps only those
+	Fork       *bool    `yaml:"fork,omitempty"`       // false excludes forks, true keeps only forks
+	Template   *bool    `yaml:"template,omitempty"`   // false excludes templates, true keeps only templates
+	Visibility []string `yaml:"visibility,omitempty"` // e.g. [public, internal]
+	Languages  []string `yaml:"languages,omitempty"`  // primary language, e.g. [Go]
+
+	Topics        []string `yaml:"topics,omitempty"`         // keep repositories with any of these topics
+	ExcludeTopics []string `yaml:"exclude_topics,omitempty"` // drop repositories with any of these topics
+
+	PushedWithin string `yaml:"pushed_within,omitempty"` // e.g. "90d", "2w" or "720h"
+}
+
+// GitHubSearch describes a GitHub search, either with a query in `gh search` syntax (methods "code" and "repos"),
+// a raw search API query (method "api") or by listing all repositories of owners (method "org").
+type GitHubSearch struct {
+	Host     string   `yaml:"host,omitempty"` // e.g. "ghe.example.com", defaults to github.com
+	Method   string   `yaml:"method,omitempty"`
+	Endpoint string   `yaml:"endpoint,omitempty"` // method "api" only: search/code (default) or search/repositories
+	Query    string   `yaml:"query,omitempty"`
+	Owners   []string `yaml:"owners,omitempty"` // method "org" only: organizations or users
+}
+
+// HostOrDefault returns the configured GitHub host, or github.com.
+func (s GitHubSearch) HostOrDefault() string {
+	if s.Host == "" {
+		return DefaultGitHubHost
+	}
+	return s.Host
+}
+
+// GitLabSearch describes a GitLab project search.
+// If Group is set, projects of that group (including subgroups) are listed,
+// otherwise the projects the user is a member of are listed.
+type GitLabSearch struct {
+	Host       string `yaml:"host,omitempty"`       // e.g. "gitlab.example.com", defaults to gitlab.com
+	Group      string `yaml:"group,omitempty"`      // e.g. "myorg/platform"
+	Topic      string `yaml:"topic,omitempty"`      // e.g. "kubernetes"
+	Query      string `yaml:"query,omitempty"`      // matched against project name/path
+	Membership bool   `yaml:"membership,omitempty"` // limit to projects the user is a member of
+}
+
+// Enabled returns true if a GitLab search has been configured.
+func (s GitLabSearch) Enabled() bool {
+	return s.Host != "" || s.Group != "" || s.Topic != "" || s.Query != "" || s.Membership
+}
+
+// HostOrDefault returns the configured GitLab host, or gitlab.com.
+func (s GitLabSearch) HostOrDefault() string {
+	if s.Host == "" {
+		return DefaultGitLabHost
+	}
+	return s.Host
+}
+
+// GiteaSearch describes a Gitea (or Forgejo) repository search. Unlike GitHub and GitLab,
+// there is no default host. If Org is set, only repositories of that organization (or user) are searched.
+type GiteaSearch struct {
+	Host  string `yaml:"host,omitempty"`  // e.g. "forgejo.example.com", required
+	Org   string `yaml:"org,omitempty"`   // e.g. "tooling"
+	Topic string `yaml:"topic,omitempty"` // e.g. "kubernetes"
+	Query string `yaml:"query,omitempty"` // matched against the repository name
+}
+
+// Enabled returns true if a Gitea search has been configured.
+func (s GiteaSearch) Enabled() bool {
+	return s.Host != "" || s.Org != "" || s.Topic != "" || s.Query != ""
+}
+
+// BitbucketSearch lists the repositories of a Bitbucket Server (or Data Center) instance.
+// Unlike GitHub and GitLab, there is no default host. If Projects is empty, all repositories
+// accessible to the user are listed.
+type BitbucketSearch struct {
+	Host     string   `yaml:"host,omitempty"`     // e.g. "bitbucket.example.com", required
+	Projects []string `yaml:"projects,omitempty"` // project keys, e.g. [PLAT, ~jdoe]
+	Query    string   `yaml:"query,omitempty"`    // matched against the repository name
+}
+
+// Enabled returns true if a Bitbucket search has been configured.
+func (s BitbucketSearch) Enabled() bool {
+	return s.Host != "" || len(s.Projects) > 0 || s.Query != ""
+}
+
+// AzureDevOpsSearch lists the repositories of an Azure DevOps organization (or an Azure DevOps Server
+// collection). If Projects is empty, the repositories of all projects of the organization are listed.
+type AzureDevOpsSearch struct {
+	Host         string   `yaml:"host,omitempty"`         // e.g. "tfs.example.com", defaults to dev.azure.com
+	Organization string   `yaml:"organization,omitempty"` // or collection, required
+	Projects     []string `yaml:"projects,omitempty"`     // e.g. [Platform]
+	Query        string   `yaml:"query,omitempty"`        // matched against the repository name
+}
+
+// Enabled returns true if an Azure DevOps search has been configured.
+func (s AzureDevOpsSearch) Enabled() bool {
+	return s.Host != "" || s.Organization != "" || len(s.Projects) > 0 || s.Query != ""
+}
+
+// HostOrDefault returns the configured Azure DevOps host, or dev.azure.com.
+func (s AzureDevOpsSearch) HostOrDefault() string {
+	if s.Host == "" {
+		return DefaultAzureDevOpsHost
+	}
+	return s.Host
+}
+
+// LocalSearch finds git repositories on the local filesystem, e.g. mirrors or copies for testing a job.
+// Relative paths are resolved against the directory of the job file.
+type LocalSearch struct {
+	Dir  string   `yaml:"dir,omitempty"`  // scanned for bare and working repositories, e.g. "../mirrors"
+	URLs []string `yaml:"urls,omitempty"` // file:// URLs or paths of single repositories
+}
+
+// Enabled returns true if a local search has been configured.
+func (s LocalSearch) Enabled() bool {
+	return s.Dir != "" || len(s.URLs) > 0
+}
+
+// PullRequests holds the pull request configuration of each hosting provider.
+type PullRequests struct {
+	GitHub      PullRequest `yaml:"github"`
+	GitLab      PullRequest `yaml:"gitlab"`
+	Gitea       PullRequest `yaml:"gitea"`
+	Bitbucket   PullRequest `yaml:"bitbucket"`
+	AzureDevOps PullRequest `yaml:"azure_devops"`
+}
+
+// For returns the pull request configuration for the given provider kind (e.g. "gitlab").
+// Providers without a configuration of their own fall back to pr.github.
+func (p PullRequests) For(kind string) PullRequest {
+	switch {
+	case kind == "gitlab" && p.GitLab.Branch != "":
+		return p.GitLab
+	case kind == "gitea" && p.Gitea.Branch != "":
+		return p.Gitea
+	case kind == "bitbucket" && p.Bitbucket.Branch != "":
+		return p.Bitbucket
+	case kind == "azuredevops" && p.AzureDevOps.Branch != "":
+		return p.AzureDevOps
+	}
+	return p.GitHub
+}
+
+// PullRequest describes the pull (or merge) request to create.
+type PullRequest struct {
+	Title  string `yaml:"title"`
+	Body   string `yaml:"body"`
+	Branch string `yaml:"branch"`
+	// Reviewers are the user names of the reviewers to request, currently only supported by Bitbucket
+	// and Azure DevOps (where email addresses also work)
+	Reviewers []string `yaml:"reviewers,omitempty"`
 }
 
 type Command struct {
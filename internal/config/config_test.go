@@ -0,0 +1,79 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/fredrikaverpil/multipr/internal/config"
+)
+
+func TestSearch_UnmarshalMapping(t *testing.T) {
+	input := `
+search:
+  github:
+    method: code
+    query: filename:go.mod
+  sources:
+    - name: team
+      combine: intersect
+      github:
+        method: org
+        owners: [acme]
+  filters:
+    fork: false
+`
+	var cfg config.JobConfig
+	if err := yaml.Unmarshal([]byte(input), &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	sources := cfg.Search.All()
+	if len(sources) != 2 {
+		t.Fatalf("expected 2 sources, got %d", len(sources))
+	}
+	if sources[0].GitHub.Query != "filename:go.mod" || sources[1].Combine != config.CombineIntersect {
+		t.Fatalf("unexpected sources: %+v", sources)
+	}
+	if cfg.Search.Filters.Fork == nil || *cfg.Search.Filters.Fork {
+		t.Fatalf("expected fork filter to be false, got %v", cfg.Search.Filters.Fork)
+	}
+}
+
+func TestSearch_UnmarshalList(t *testing.T) {
+	input := `
+search:
+  - name: a
+    repos: [github.com/acme/a]
+  - name: b
+    combine: subtract
+    repos: [github.com/acme/b]
+`
+	var cfg config.JobConfig
+	if err := yaml.Unmarshal([]byte(input), &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	sources := cfg.Search.All()
+	if len(sources) != 2 || sources[0].Name != "a" || sources[1].Combine != config.CombineSubtract {
+		t.Fatalf("unexpected sources: %+v", sources)
+	}
+}
+
+func TestClone_Unmarshal(t *testing.T) {
+	input := `
+clone:
+  depth: 1
+  sparse: [/.github/]
+  fetch_ttl: 15m
+`
+	var cfg config.JobConfig
+	if err := yaml.Unmarshal([]byte(input), &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Clone.Depth != 1 || len(cfg.Clone.Sparse) != 1 || cfg.Clone.FetchTTL != 15*time.Minute {
+		t.Fatalf("unexpected clone options: %+v", cfg.Clone)
+	}
+}
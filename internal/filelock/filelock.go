@@ -0,0 +1,130 @@
+// Package filelock provides advisory locks between processes, e.g. for caches shared by concurrent jobs.
+// Locks are files created exclusively, so they work on every platform. While a lock is held, its file is
+// touched periodically, so that the lock of a process which died without unlocking can be taken over.
+package filelock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/fredrikaverpil/multipr/internal/log"
+)
+
+var (
+	// heartbeat is how often a held lock is touched
+	heartbeat = 10 * time.Second
+	// stale is how long a lock may go untouched before it is taken over
+	stale = 6 * heartbeat
+	// poll is how often a held lock is checked while waiting for it
+	poll = 200 * time.Millisecond
+)
+
+// Lock waits until the lock at path is acquired, or the context is done. The returned function releases it.
+func Lock(ctx context.Context, path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), log.DefaultFilePerms); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+
+	for {
+		acquired, err := tryLock(path)
+		if err != nil {
+			return nil, err
+		}
+		if acquired {
+			return hold(path), nil
+		}
+
+		if isStale(path) {
+			// The holder died without unlocking
+			if err = takeOver(path); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to acquire lock %s: %w", path, ctx.Err())
+		case <-time.After(poll):
+		}
+	}
+}
+
+// takeOver removes the stale lock at path. Processes take turns taking over a lock, using a second lock,
+// and check again whether it is stale before removing it, so that a lock which another process just took over
+// is not removed.
+func takeOver(path string) error {
+	guard := path + ".takeover"
+	acquired, err := tryLock(guard)
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		if isStale(guard) {
+			// The process taking over the lock died, which it is only briefly able to
+			_ = os.Remove(guard)
+		}
+		return nil
+	}
+	defer os.Remove(guard)
+
+	if isStale(path) {
+		if err = os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to remove stale lock %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func isStale(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && time.Since(info.ModTime()) > stale
+}
+
+// tryLock creates the lock file, which holds the process ID of the holder for troubleshooting.
+func tryLock(path string) (bool, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, log.RegularFilePerms)
+	if errors.Is(err, fs.ErrExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to create lock %s: %w", path, err)
+	}
+	_, err = f.WriteString(strconv.Itoa(os.Getpid()) + "\n")
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return false, fmt.Errorf("failed to write lock %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// hold touches the lock file until the returned function is called, which removes it.
+func hold(path string) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				_ = os.Chtimes(path, now, now)
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		_ = os.Remove(path)
+	}
+}
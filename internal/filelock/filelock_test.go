@@ -0,0 +1,120 @@
+package filelock //nolint:testpackage // internal testing needed for the timings
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLock_WaitsForUnlock(t *testing.T) {
+	poll = time.Millisecond
+	path := filepath.Join(t.TempDir(), "mirrors", "repo.lock")
+
+	unlock, err := Lock(t.Context(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acquired := make(chan func())
+	go func() {
+		second, lockErr := Lock(t.Context(), path)
+		if lockErr != nil {
+			t.Error(lockErr)
+		}
+		acquired <- second
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("expected the lock to be held")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+	select {
+	case second := <-acquired:
+		second()
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the lock to be acquired after unlocking")
+	}
+	if _, err = os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected the lock file to be removed, got %v", err)
+	}
+}
+
+func TestLock_TakesOverStaleLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "repo.lock")
+	if err := os.WriteFile(path, []byte("1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * stale)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	unlock, err := Lock(t.Context(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+}
+
+func TestLock_TakesOverStaleLockOnce(t *testing.T) {
+	poll = time.Millisecond
+	path := filepath.Join(t.TempDir(), "repo.lock")
+	if err := os.WriteFile(path, []byte("1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * stale)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	// All waiters find the lock stale at the same time, but only one of them holds it at a time
+	var holders, maxHolders atomic.Int32
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			unlock, err := Lock(t.Context(), path)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			n := holders.Add(1)
+			for {
+				if current := maxHolders.Load(); n <= current || maxHolders.CompareAndSwap(current, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			holders.Add(-1)
+			unlock()
+		})
+	}
+	wg.Wait()
+
+	if got := maxHolders.Load(); got != 1 {
+		t.Fatalf("expected the lock to be held once at a time, got %d holders", got)
+	}
+}
+
+func TestLock_ContextDone(t *testing.T) {
+	poll = time.Millisecond
+	path := filepath.Join(t.TempDir(), "repo.lock")
+
+	unlock, err := Lock(t.Context(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+	if _, err = Lock(ctx, path); err == nil {
+		t.Fatal("expected an error when the context is done")
+	}
+}
@@ -0,0 +1,133 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fredrikaverpil/multipr/internal/command"
+	"github.com/fredrikaverpil/multipr/internal/filelock"
+	"github.com/fredrikaverpil/multipr/internal/log"
+)
+
+// Mirrors is a cache of bare mirrors of repositories, which is shared by all jobs, e.g. ~/.cache/multipr/mirrors.
+// Clones of a job borrow the objects of the mirror (or copy them, if shallow), so each repository is only
+// downloaded once.
+type Mirrors struct {
+	dir      string
+	executor *command.Executor
+	log      *log.Logger
+}
+
+// NewMirrors creates a mirror cache in the directory.
+func NewMirrors(dir string, executor *command.Executor, logger *log.Logger) *Mirrors {
+	return &Mirrors{dir: dir, executor: executor, log: logger}
+}
+
+// unsafePathChars are replaced in the clone filter when it is part of the path of a mirror.
+var unsafePathChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Path returns the path of the mirror of the repository, e.g. <dir>/github.com/owner/repo.git.
+// Mirrors are kept separately per clone filter and depth, e.g. <dir>/github.com/owner/repo.blob-none.depth-1.git,
+// as clones cannot borrow the objects they need from a partial or shallow mirror.
+func (m *Mirrors) Path(r *Repo) string {
+	name := filepath.FromSlash(r.FullName)
+	if r.CloneOptions.Filter != "" {
+		name += "." + unsafePathChars.ReplaceAllString(r.CloneOptions.Filter, "-")
+	}
+	if r.CloneOptions.Depth > 0 {
+		name += ".depth-" + strconv.Itoa(r.CloneOptions.Depth)
+	}
+	return filepath.Join(m.dir, r.Host, name+".git")
+}
+
+// Use creates or updates the mirror of the repository, and calls fn with its path while holding its lock,
+// so that concurrent jobs do not update the mirror at the same time.
+func (m *Mirrors) Use(ctx context.Context, r *Repo, fn func(path string) error) error {
+	path := m.Path(r)
+	unlock, err := filelock.Lock(ctx, path+".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err = m.update(ctx, r, path); err != nil {
+		return fmt.Errorf("failed to update mirror of %s: %w", r.String(), err)
+	}
+	return fn(path)
+}
+
+// update clones the mirror of the repository, or fetches it if it exists. Only branches and tags are mirrored,
+// with the clone filter and depth of the repository.
+func (m *Mirrors) update(ctx context.Context, r *Repo, path string) error {
+	env := command.WithEnv(r.Env...)
+
+	if _, err := os.Stat(path); err == nil {
+		m.log.Info(fmt.Sprintf("Updating mirror of %s...", r.String()))
+		// Partial mirrors keep their filter
+		args := []string{"fetch", "--prune"}
+		if r.CloneOptions.Depth > 0 {
+			args = append(args, "--depth="+strconv.Itoa(r.CloneOptions.Depth))
+		}
+		_, err = m.executor.Execute(ctx, "git", append(args, "origin"), command.WithDir(path), env)
+		return err
+	}
+
+	m.log.Info(fmt.Sprintf("Creating mirror of %s at %s...", r.String(), path))
+	args := []string{"clone", "--bare"}
+	if r.CloneOptions.Depth > 0 {
+		args = append(args, "--depth="+strconv.Itoa(r.CloneOptions.Depth), "--no-single-branch")
+	}
+	if r.CloneOptions.Filter != "" {
+		args = append(args, "--filter="+r.CloneOptions.Filter)
+	}
+	url := r.provider.CloneURL(r.FullName)
+	if _, err := m.executor.Execute(ctx, "git", append(args, url, path), env); err != nil {
+		_ = os.RemoveAll(path)
+		return err
+	}
+
+	configs := [][]string{
+		{"remote.origin.fetch", "+refs/heads/*:refs/heads/*"},
+		// Clones of jobs may still use objects which are no longer referenced by the mirror
+		{"gc.pruneExpire", "never"},
+		// Shallow clones copy the mirror, with the same filter
+		{"uploadpack.allowFilter", "true"},
+	}
+	for _, c := range configs {
+		args := append([]string{"config"}, c...)
+		if _, err := m.executor.Execute(ctx, "git", args, command.WithDir(path)); err != nil {
+			_ = os.RemoveAll(path)
+			return err
+		}
+	}
+	return nil
+}
+
+// cloneFromMirror clones the repository from its shallow mirror, as clones cannot borrow the objects of a shallow
+// repository. The clone is checked out once origin is the repository, which provides the objects that a partial
+// mirror lacks.
+func (r *Repo) cloneFromMirror(ctx context.Context, mirror string, flags []string) error {
+	// Local paths are cloned in full, whereas file:// URLs are cloned like remote repositories
+	url := filepath.ToSlash(mirror)
+	if !strings.HasPrefix(url, "/") {
+		url = "/" + url
+	}
+	flags = append(flags, "--no-checkout")
+	if err := r.executor.GitClone(ctx, "file://"+url, r.LocalPath(), flags); err != nil {
+		return err
+	}
+	if err := r.SetOrigin(ctx); err != nil {
+		return err
+	}
+
+	args := []string{"checkout", "--force"}
+	if _, err := r.executor.Execute(ctx, "git", args, command.WithDir(r.LocalPath()), command.WithEnv(r.Env...)); err != nil {
+		return fmt.Errorf("failed to check out clone: %w", err)
+	}
+	return nil
+}
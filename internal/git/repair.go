@@ -0,0 +1,127 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fredrikaverpil/multipr/internal/command"
+)
+
+// staleLocks are the lock files git leaves behind when it is interrupted, relative to the .git directory.
+var staleLocks = []string{"index.lock", "HEAD.lock", "packed-refs.lock", "shallow.lock", "config.lock"}
+
+// staleLockAge is the age from which a lock file is considered left behind, rather than held by a git process
+// which is still running in the clone (e.g. started by the user).
+const staleLockAge = 10 * time.Minute
+
+// inProgress is an operation which was interrupted, detected by the state it keeps in the .git directory.
+type inProgress struct {
+	name  string
+	state []string // any of these paths exists while the operation is in progress
+	abort []string // git arguments which abort the operation
+}
+
+var operations = []inProgress{
+	{name: "rebase", state: []string{"rebase-merge", "rebase-apply/rebasing"}, abort: []string{"rebase", "--abort"}},
+	{name: "am", state: []string{"rebase-apply/applying"}, abort: []string{"am", "--abort"}},
+	{name: "merge", state: []string{"MERGE_HEAD"}, abort: []string{"merge", "--abort"}},
+	{name: "cherry-pick", state: []string{"CHERRY_PICK_HEAD"}, abort: []string{"cherry-pick", "--abort"}},
+	{name: "revert", state: []string{"REVERT_HEAD"}, abort: []string{"revert", "--abort"}},
+}
+
+// Repair fixes the state a clone is left in when a previous run was interrupted: it removes stale lock files
+// (older than staleLockAge), aborts operations in progress, restores refs/remotes/origin/HEAD and checks out
+// the default branch if HEAD is detached. It returns a description of each fix.
+// Repair must not be called while other git commands run in the clone.
+func (r *Repo) Repair(ctx context.Context) ([]string, error) {
+	gitDir := filepath.Join(r.LocalPath(), ".git")
+	var fixes []string
+
+	for _, lock := range staleLocks {
+		path := filepath.Join(gitDir, lock)
+		info, err := os.Stat(path)
+		if err != nil || time.Since(info.ModTime()) < staleLockAge {
+			continue
+		}
+		if err = os.Remove(path); err != nil {
+			return fixes, fmt.Errorf("failed to remove stale lock %s: %w", lock, err)
+		}
+		fixes = append(fixes, "removed stale "+lock)
+	}
+
+	for _, op := range operations {
+		if !anyExists(gitDir, op.state) {
+			continue
+		}
+		if _, err := r.executor.Execute(ctx, "git", op.abort, command.WithDir(r.LocalPath())); err != nil {
+			// The state is inconsistent, but the default branch is reset when it is checked out anyway
+			for _, state := range op.state {
+				// e.g. all of rebase-apply, not only the file marking the operation
+				state, _, _ = strings.Cut(state, "/")
+				if removeErr := os.RemoveAll(filepath.Join(gitDir, state)); removeErr != nil {
+					return fixes, fmt.Errorf("failed to abort %s: %w", op.name, errors.Join(err, removeErr))
+				}
+			}
+			fixes = append(fixes, "removed the state of an interrupted "+op.name)
+			continue
+		}
+		fixes = append(fixes, "aborted an interrupted "+op.name)
+	}
+
+	if _, err := r.DefaultBranch(ctx); err != nil {
+		fix, setErr := r.restoreOriginHead(ctx)
+		if setErr != nil {
+			return fixes, setErr
+		}
+		fixes = append(fixes, fix)
+	}
+
+	head := []string{"symbolic-ref", "-q", "HEAD"}
+	if _, err := r.executor.Execute(ctx, "git", head, command.WithDir(r.LocalPath())); err != nil {
+		defaultBranch, branchErr := r.DefaultBranch(ctx)
+		if branchErr != nil {
+			return fixes, branchErr
+		}
+		args := []string{"checkout", "--force", "-B", defaultBranch, "origin/" + defaultBranch}
+		if _, err = r.executor.Execute(ctx, "git", args, command.WithDir(r.LocalPath())); err != nil {
+			return fixes, fmt.Errorf("failed to check out %s from detached HEAD: %w", defaultBranch, err)
+		}
+		fixes = append(fixes, "checked out "+defaultBranch+" from detached HEAD")
+	}
+
+	return fixes, nil
+}
+
+// restoreOriginHead sets refs/remotes/origin/HEAD from the remote, or else from the default branch
+// reported by the provider.
+func (r *Repo) restoreOriginHead(ctx context.Context) (string, error) {
+	args := []string{"remote", "set-head", "origin", "--auto"}
+	_, err := r.executor.Execute(ctx, "git", args, command.WithDir(r.LocalPath()), command.WithEnv(r.Env...))
+	if err == nil {
+		return "restored origin/HEAD from the remote", nil
+	}
+
+	meta, apiErr := r.provider.Repository(ctx, r.FullName)
+	if apiErr != nil || meta.DefaultBranch == "" {
+		return "", fmt.Errorf("failed to restore origin/HEAD: %w", errors.Join(err, apiErr))
+	}
+	args = []string{"remote", "set-head", "origin", meta.DefaultBranch}
+	if _, err = r.executor.Execute(ctx, "git", args, command.WithDir(r.LocalPath())); err != nil {
+		return "", fmt.Errorf("failed to restore origin/HEAD: %w", err)
+	}
+	return "restored origin/HEAD from the default branch of the API", nil
+}
+
+func anyExists(dir string, paths []string) bool {
+	for _, path := range paths {
+		if _, err := os.Stat(filepath.Join(dir, path)); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -5,43 +5,69 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fredrikaverpil/multipr/internal/command"
+	"github.com/fredrikaverpil/multipr/internal/config"
 	"github.com/fredrikaverpil/multipr/internal/log"
+	"github.com/fredrikaverpil/multipr/internal/provider"
 )
 
 const (
 	DefaultFilePerms = 0o755
+
+	// lastFetchFile records when the clone was last fetched, in its .git directory
+	lastFetchFile = "multipr-last-fetch"
 )
 
 type Repo struct {
 	Host     string
 	FullName string
 	ReposDir string
+	// Env is added to the environment of git commands against the remote, e.g. for credentials
+	Env []string
+	// CloneOptions limits what is cloned and fetched, see config.Clone
+	CloneOptions config.Clone
+	// Mirrors is the shared mirror cache which clones borrow objects from, or nil to clone in full
+	Mirrors *Mirrors
+	// Metadata is the metadata returned by the search, or nil if it returned none
+	Metadata *provider.Repository
+	provider provider.Provider
 	executor *command.Executor
 	log      *log.Logger
 }
 
-func NewRepo(host, fullName, reposDir string, executor *command.Executor, logger *log.Logger) *Repo {
+func NewRepo(
+	p provider.Provider,
+	fullName, reposDir string,
+	executor *command.Executor,
+	logger *log.Logger,
+) *Repo {
 	return &Repo{
-		Host:     host,     // e.g. "github.com"
+		Host:     p.Host(), // e.g. "github.com"
 		FullName: fullName, // e.g. "fredrikaverpil/multipr"
 		ReposDir: reposDir,
+		provider: p,
 		executor: executor,
 		log:      logger,
 	}
 }
 
+// Provider returns the hosting provider of the repository.
+func (r *Repo) Provider() provider.Provider {
+	return r.provider
+}
+
 func (r *Repo) String() string {
 	return fmt.Sprintf("%s/%s", r.Host, r.FullName)
 }
 
+// LocalPath returns the path of the clone, e.g. <reposDir>/github.com/owner/repo.
+// GitLab full names may contain subgroups, which become nested directories.
 func (r *Repo) LocalPath() string {
-	parts := strings.Split(r.FullName, "/")
-	username := parts[0]
-	repoName := parts[1]
-	return filepath.Join(r.ReposDir, r.Host, username, repoName)
+	return filepath.Join(r.ReposDir, r.Host, filepath.FromSlash(r.FullName))
 }
 
 func (r *Repo) Clone(ctx context.Context) error {
@@ -50,12 +76,64 @@ func (r *Repo) Clone(ctx context.Context) error {
 		return nil
 	}
 
-	// TODO: switch/case on host (or use interface?)
-	return r.executor.GHClone(ctx, r.FullName, r.LocalPath())
+	var flags []string
+	if r.CloneOptions.Depth > 0 {
+		// Shallow clones only include the default branch by default, but the PR branch is fetched too
+		flags = append(flags, "--depth="+strconv.Itoa(r.CloneOptions.Depth), "--no-single-branch")
+	}
+	if r.CloneOptions.Filter != "" {
+		flags = append(flags, "--filter="+r.CloneOptions.Filter)
+	}
+	if len(r.CloneOptions.Sparse) > 0 {
+		flags = append(flags, "--sparse")
+	}
+
+	clone := func() error {
+		url := r.provider.CloneURL(r.FullName)
+		return r.executor.GitClone(ctx, url, r.LocalPath(), flags, command.WithEnv(r.Env...))
+	}
+
+	var err error
+	if r.Mirrors != nil {
+		// The clone keeps the remote as origin, and borrows the objects of the mirror
+		err = r.Mirrors.Use(ctx, r, func(mirror string) error {
+			if r.CloneOptions.Depth > 0 {
+				return r.cloneFromMirror(ctx, mirror, flags)
+			}
+			flags = append(flags, "--reference="+mirror)
+			return clone()
+		})
+	} else {
+		err = clone()
+	}
+	if err != nil {
+		return err
+	}
+	if err = r.recordFetch(); err != nil {
+		return err
+	}
+	return r.applySparseCheckout(ctx)
 }
 
-// CheckoutDefaultBranch checks out the default branch and resets it.
-func (r *Repo) CheckoutDefaultBranch(ctx context.Context) error {
+// applySparseCheckout sets the sparse-checkout patterns, so that changed patterns also apply to existing clones.
+func (r *Repo) applySparseCheckout(ctx context.Context) error {
+	if len(r.CloneOptions.Sparse) == 0 {
+		return nil
+	}
+	return r.executor.GitSparseCheckout(ctx, r.LocalPath(), r.CloneOptions.Sparse)
+}
+
+// SetOrigin points the origin remote of the clone at the clone URL of the repository, e.g. after it was renamed.
+func (r *Repo) SetOrigin(ctx context.Context) error {
+	args := []string{"remote", "set-url", "origin", r.provider.CloneURL(r.FullName)}
+	if _, err := r.executor.Execute(ctx, "git", args, command.WithDir(r.LocalPath())); err != nil {
+		return fmt.Errorf("failed to set origin: %w", err)
+	}
+	return nil
+}
+
+// DefaultBranch returns the default branch of the remote, as recorded in the clone.
+func (r *Repo) DefaultBranch(ctx context.Context) (string, error) {
 	// Use git symbolic-ref to get the default branch reference
 	result, err := r.executor.Execute(
 		ctx,
@@ -64,15 +142,28 @@ func (r *Repo) CheckoutDefaultBranch(ctx context.Context) error {
 		command.WithDir(r.LocalPath()),
 	)
 	if err != nil {
-		return fmt.Errorf("failed to get default branch: %w", err)
+		return "", fmt.Errorf("failed to get default branch: %w", err)
 	}
 
 	// Extract just the branch name from the full reference path
 	// Input example: "refs/remotes/origin/main"
 	refPath := strings.TrimSpace(result.Stdout)
-	defaultBranch := strings.TrimPrefix(refPath, "refs/remotes/origin/")
+	return strings.TrimPrefix(refPath, "refs/remotes/origin/"), nil
+}
 
-	if err = r.executor.GitFetchAll(ctx, r.LocalPath()); err != nil {
+// CheckoutDefaultBranch checks out the default branch and resets it.
+// Shallow clones are fetched at the configured depth, and partial clones keep their filter.
+// Fetching is skipped if the clone was fetched within the fetch TTL.
+func (r *Repo) CheckoutDefaultBranch(ctx context.Context) error {
+	defaultBranch, err := r.DefaultBranch(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = r.fetch(ctx); err != nil {
+		return err
+	}
+	if err = r.applySparseCheckout(ctx); err != nil {
 		return err
 	}
 	if err = r.executor.GitCheckout(ctx, r.LocalPath(), defaultBranch); err != nil {
@@ -85,6 +176,45 @@ func (r *Repo) CheckoutDefaultBranch(ctx context.Context) error {
 	return nil
 }
 
+// fetch fetches all remotes, unless the clone was fetched within the fetch TTL.
+func (r *Repo) fetch(ctx context.Context) error {
+	if last, ok := r.LastFetch(); ok && time.Since(last) < r.CloneOptions.FetchTTL {
+		r.log.Debug(fmt.Sprintf("Skipping fetch of %s, fetched at %s", r.String(), last.Format(time.RFC3339)))
+		return nil
+	}
+
+	var flags []string
+	if r.CloneOptions.Depth > 0 {
+		flags = append(flags, "--depth="+strconv.Itoa(r.CloneOptions.Depth))
+	}
+	if err := r.executor.GitFetchAll(ctx, r.LocalPath(), flags, command.WithEnv(r.Env...)); err != nil {
+		return err
+	}
+	return r.recordFetch()
+}
+
+// LastFetch returns when the clone was last cloned or fetched, if it was recorded.
+func (r *Repo) LastFetch() (time.Time, bool) {
+	data, err := os.ReadFile(filepath.Join(r.LocalPath(), ".git", lastFetchFile))
+	if err != nil {
+		return time.Time{}, false
+	}
+	last, err := time.Parse(time.RFC3339, strings.TrimSpace(string(data)))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return last, true
+}
+
+func (r *Repo) recordFetch() error {
+	path := filepath.Join(r.LocalPath(), ".git", lastFetchFile)
+	data := []byte(time.Now().UTC().Format(time.RFC3339) + "\n")
+	if err := os.WriteFile(path, data, log.RegularFilePerms); err != nil {
+		return fmt.Errorf("failed to record fetch time: %w", err)
+	}
+	return nil
+}
+
 func (r *Repo) ShowDiff(ctx context.Context) error {
 	return r.executor.GitDiff(ctx, r.LocalPath())
 }
@@ -94,28 +224,81 @@ func (r *Repo) CheckoutNewBranch(ctx context.Context, branchName string) error {
 	return r.executor.GitCheckout(ctx, r.LocalPath(), branchName)
 }
 
-// CheckPRExists checks if a PR already exists for the given branch.
-func (r *Repo) CheckPRExists(ctx context.Context, branchName string) (bool, string, error) {
-	result, err := r.executor.ExecuteWithShell(
+// FindPR returns the open pull request for the given branch, or nil if there is none.
+func (r *Repo) FindPR(ctx context.Context, branchName string) (*provider.PullRequest, error) {
+	pr, err := r.provider.FindPR(ctx, r.FullName, branchName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for existing PR: %w", err)
+	}
+	return pr, nil
+}
+
+// PushBranch pushes the current branch to the remote.
+func (r *Repo) PushBranch(ctx context.Context, branchName string) error {
+	return r.executor.GitPushForce(ctx, r.LocalPath(), branchName, command.WithEnv(r.Env...))
+}
+
+// CommitsAhead returns the number of commits of HEAD which are not on the base branch of the remote.
+func (r *Repo) CommitsAhead(ctx context.Context, base string) (int, error) {
+	result, err := r.executor.Execute(
 		ctx,
-		fmt.Sprintf("gh pr list --head %s --json number --jq '.[0].number'", branchName),
-		"",
-		command.WithDir(r.LocalPath()))
+		"git",
+		[]string{"rev-list", "--count", "origin/" + base + "..HEAD"},
+		command.WithDir(r.LocalPath()),
+	)
 	if err != nil {
-		return false, "", fmt.Errorf("failed to check for existing PR: %w", err)
+		return 0, fmt.Errorf("failed to count commits: %w", err)
 	}
+	return strconv.Atoi(strings.TrimSpace(result.Stdout))
+}
 
-	// If output is empty, no PR exists
-	if result.Stdout == "" {
-		return false, "", nil
+// SetTrailer amends the last commit, adding the trailer or replacing an existing one with the same key.
+// Hooks are not run, so that e.g. a Gerrit commit-msg hook does not add a trailer of its own.
+func (r *Repo) SetTrailer(ctx context.Context, key, value string) error {
+	_, err := r.executor.Execute(
+		ctx,
+		"git",
+		[]string{
+			"-c", "trailer." + key + ".ifexists=replace",
+			"commit", "--amend", "--no-edit", "--no-verify", "--trailer", key + ": " + value,
+		},
+		command.WithDir(r.LocalPath()),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to set %s trailer: %w", key, err)
 	}
+	return nil
+}
 
-	return true, result.Stdout, nil
+// PushRef pushes HEAD to the ref of the remote, e.g. "refs/for/main".
+func (r *Repo) PushRef(ctx context.Context, ref string) error {
+	_, err := r.executor.Execute(
+		ctx,
+		"git",
+		[]string{"push", "origin", "HEAD:" + ref},
+		command.WithDir(r.LocalPath()),
+		command.WithEnv(r.Env...),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to push to %s: %w", ref, err)
+	}
+	return nil
 }
 
-// PushBranch pushes the current branch to the remote.
-func (r *Repo) PushBranch(ctx context.Context, branchName string) error {
-	return r.executor.GitPushForce(ctx, r.LocalPath(), branchName)
+// FormatPatch returns the commits of HEAD which are not on the base branch of the remote as patches,
+// in mbox format.
+func (r *Repo) FormatPatch(ctx context.Context, base string, args ...string) (string, error) {
+	args = append([]string{"format-patch", "--stdout"}, args...)
+	result, err := r.executor.Execute(
+		ctx,
+		"git",
+		append(args, "origin/"+base+"..HEAD"),
+		command.WithDir(r.LocalPath()),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to format patches: %w", err)
+	}
+	return result.Stdout, nil
 }
 
 // CreateCommit creates a commit with the given message.
@@ -138,9 +321,3 @@ func (r *Repo) HasChanges(ctx context.Context) (bool, error) {
 
 	return result.Stdout != "", nil
 }
-
-// TODO: implement
-// func (r *Repo) Publish() error {
-//  // TODO: switch/case on host (or use interface?)
-// 	return r.executor.GHPublish(r.LocalPath())
-// }
@@ -0,0 +1,151 @@
+package gitea_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fredrikaverpil/multipr/internal/gitea"
+	"github.com/fredrikaverpil/multipr/internal/gitea/giteatest"
+)
+
+func newTestClient(server *giteatest.Server, token string) *gitea.Client {
+	return gitea.NewClient(server.URL+"/api/v1", token)
+}
+
+func TestClient_SearchRepositories(t *testing.T) {
+	server := giteatest.NewServer(t)
+	server.AddRepository(gitea.Repository{FullName: "tooling/api", Topics: []string{"go"}}, nil)
+	server.AddRepository(gitea.Repository{FullName: "tooling/web", Topics: []string{"frontend"}}, nil)
+	server.AddRepository(gitea.Repository{FullName: "other/api", Topics: []string{"go"}}, nil)
+	client := newTestClient(server, giteatest.Token)
+
+	owner, err := client.GetUser(t.Context(), "tooling")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts gitea.SearchRepositoriesOptions
+		want string
+	}{
+		{name: "all", want: "other/api,tooling/api,tooling/web"},
+		{name: "keyword", opts: gitea.SearchRepositoriesOptions{Query: "API"}, want: "other/api,tooling/api"},
+		{name: "topic", opts: gitea.SearchRepositoriesOptions{Query: "go", Topic: true}, want: "other/api,tooling/api"},
+		{name: "owner", opts: gitea.SearchRepositoriesOptions{OwnerID: owner.ID}, want: "tooling/api,tooling/web"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos, searchErr := client.SearchRepositories(t.Context(), tt.opts)
+			if searchErr != nil {
+				t.Fatal(searchErr)
+			}
+			var got []string
+			for _, r := range repos {
+				got = append(got, r.FullName)
+			}
+			if strings.Join(got, ",") != tt.want {
+				t.Fatalf("got %v, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err = client.GetUser(t.Context(), "missing"); !gitea.IsNotFound(err) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestClient_PullRequestLifecycle(t *testing.T) {
+	server := giteatest.NewServer(t)
+	server.AddRepository(gitea.Repository{FullName: "tooling/api"}, map[string]string{"README.md": "# api\n"})
+	client := newTestClient(server, giteatest.Token)
+	ctx := t.Context()
+
+	pr, err := client.FindPullRequest(ctx, "tooling/api", "main")
+	if err != nil || pr != nil {
+		t.Fatalf("expected no pull request, got %+v, %v", pr, err)
+	}
+
+	pr, err = client.CreatePullRequest(ctx, "tooling/api", gitea.CreatePullRequestOptions{
+		Title: gitea.DraftTitle("chore: update", true), Head: "main", Base: "main", Body: "body",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.Title != "WIP: chore: update" || !gitea.IsDraft(pr.Title) {
+		t.Fatalf("expected draft pull request, got %+v", pr)
+	}
+
+	if _, err = client.UpdatePullRequest(ctx, "tooling/api", pr.Number, gitea.UpdatePullRequestOptions{
+		Title: gitea.DraftTitle(pr.Title, false),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := client.FindPullRequest(ctx, "tooling/api", "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.Number != pr.Number || found.Title != "chore: update" || found.Body != "body" {
+		t.Fatalf("unexpected pull request: %+v", found)
+	}
+}
+
+func TestClient_PaginatesCappedPages(t *testing.T) {
+	server := giteatest.NewServer(t)
+	server.MaxResponseItems = 2
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		server.AddRepository(gitea.Repository{FullName: "tooling/" + name}, nil)
+	}
+	for _, head := range []string{"one", "two", "three"} {
+		server.AddPullRequest("tooling/a", head)
+	}
+	client := newTestClient(server, giteatest.Token)
+
+	repos, err := client.SearchRepositories(t.Context(), gitea.SearchRepositoriesOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 5 {
+		t.Fatalf("expected all 5 repositories across capped pages, got %d", len(repos))
+	}
+
+	pr, err := client.FindPullRequest(t.Context(), "tooling/a", "three")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr == nil || pr.Number != 3 {
+		t.Fatalf("expected the pull request on the second page, got %+v", pr)
+	}
+}
+
+func TestDraftTitle(t *testing.T) {
+	tests := []struct {
+		title string
+		draft bool
+		want  string
+	}{
+		{title: "chore: update", draft: true, want: "WIP: chore: update"},
+		{title: "WIP: chore: update", draft: true, want: "WIP: chore: update"},
+		{title: "wip: chore: update", draft: false, want: "chore: update"},
+		{title: "[WIP] chore: update", draft: false, want: "chore: update"},
+		{title: "chore: update", draft: false, want: "chore: update"},
+	}
+	for _, tt := range tests {
+		if got := gitea.DraftTitle(tt.title, tt.draft); got != tt.want {
+			t.Errorf("DraftTitle(%q, %v) = %q, want %q", tt.title, tt.draft, got, tt.want)
+		}
+	}
+}
+
+func TestClient_Unauthorized(t *testing.T) {
+	server := giteatest.NewServer(t)
+	client := newTestClient(server, "wrong")
+
+	_, err := client.AuthenticatedUser(t.Context())
+	var apiErr *gitea.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 401 || apiErr.Message != "token is required" {
+		t.Fatalf("expected unauthorized error, got %v", err)
+	}
+}
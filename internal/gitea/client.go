@@ -0,0 +1,159 @@
+// Package gitea is a minimal Gitea REST API (v1) client, which also works with Forgejo.
+//
+// https://docs.gitea.com/api/
+package gitea
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Gitea caps the page size at 50 by default (MAX_RESPONSE_ITEMS), but instances may cap it lower.
+	perPage        = 50
+	requestTimeout = 30 * time.Second
+	apiPath        = "/api/v1"
+)
+
+// Client talks to a single Gitea or Forgejo instance.
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a new client, where baseURL is the API root, e.g. "https://gitea.example.com/api/v1".
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		token:      token,
+		httpClient: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// BaseURL returns the API root for the given host, e.g. "gitea.example.com".
+func BaseURL(host string) string {
+	return "https://" + host + apiPath
+}
+
+// Token returns the token requests are authenticated with, or an empty string.
+func (c *Client) Token() string {
+	return c.token
+}
+
+// WebURL returns the root of the web interface (and git remotes) of the instance.
+func (c *Client) WebURL() string {
+	return strings.TrimSuffix(c.baseURL, apiPath)
+}
+
+// APIError is returned when Gitea responds with a non-2xx status code.
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("gitea: %s %s failed with status %d: %s", e.Method, e.Path, e.StatusCode, e.Message)
+}
+
+// IsNotFound reports whether err is an *APIError with status 404.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// User is a subset of the Gitea user resource. Organizations are users too.
+type User struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login"`
+}
+
+// AuthenticatedUser returns the user the token belongs to.
+func (c *Client) AuthenticatedUser(ctx context.Context) (*User, error) {
+	var u User
+	if err := c.do(ctx, http.MethodGet, "/user", nil, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// GetUser fetches a user or organization by name.
+func (c *Client) GetUser(ctx context.Context, name string) (*User, error) {
+	var u User
+	if err := c.do(ctx, http.MethodGet, "/users/"+url.PathEscape(name), nil, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request body: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "token "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("gitea: %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &APIError{
+			Method:     method,
+			Path:       path,
+			StatusCode: resp.StatusCode,
+			Message:    errorMessage(data),
+		}
+	}
+
+	if out != nil && len(data) > 0 {
+		if err = json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// errorMessage returns the message of a Gitea error response, or the raw body.
+func errorMessage(body []byte) string {
+	var response struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &response); err == nil && response.Message != "" {
+		return response.Message
+	}
+	return strings.TrimSpace(string(body))
+}
@@ -0,0 +1,313 @@
+// Package giteatest is an in-memory fake of the Gitea (and Forgejo) REST API. It also serves its
+// repositories over git smart HTTP, so that searching, cloning, pushing and opening pull requests
+// can be exercised end-to-end without network access.
+package giteatest
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fredrikaverpil/multipr/internal/gitea"
+	"github.com/fredrikaverpil/multipr/internal/gittest"
+)
+
+const (
+	// Token is the only token accepted by the server.
+	Token = "giteatest-token"
+	// Login is the user the token belongs to.
+	Login = "gitea-user"
+
+	defaultBranch = "main"
+	defaultLimit  = 30
+)
+
+// Server is a fake Gitea instance. Use URL+"/api/v1" as the base URL of a gitea.Client.
+type Server struct {
+	URL string
+	// MaxResponseItems caps the page size like Gitea's MAX_RESPONSE_ITEMS setting, if it is set
+	MaxResponseItems int
+
+	t   testing.TB
+	git *gittest.Repos // bare repositories, <owner>/<repo>.git
+
+	mu     sync.Mutex
+	repos  map[string]gitea.Repository
+	owners map[string]int64 // users and organizations by name
+	prs    map[string][]*gitea.PullRequest
+}
+
+// NewServer starts a fake Gitea instance, which is closed when the test ends.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+
+	s := &Server{
+		t:      t,
+		git:    gittest.New(t),
+		repos:  make(map[string]gitea.Repository),
+		owners: map[string]int64{Login: 1},
+		prs:    make(map[string][]*gitea.PullRequest),
+	}
+
+	api := http.NewServeMux()
+	api.HandleFunc("GET /api/v1/user", s.getAuthenticatedUser)
+	api.HandleFunc("GET /api/v1/users/{name}", s.getUser)
+	api.HandleFunc("GET /api/v1/repos/search", s.searchRepositories)
+	api.HandleFunc("GET /api/v1/repos/{owner}/{repo}", s.getRepository)
+	api.HandleFunc("GET /api/v1/repos/{owner}/{repo}/pulls", s.listPullRequests)
+	api.HandleFunc("POST /api/v1/repos/{owner}/{repo}/pulls", s.createPullRequest)
+	api.HandleFunc("PATCH /api/v1/repos/{owner}/{repo}/pulls/{number}", s.updatePullRequest)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if gittest.IsGitRequest(r) {
+			s.git.ServeHTTP(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "token "+Token {
+			writeError(w, http.StatusUnauthorized, "token is required")
+			return
+		}
+		api.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	s.URL = server.URL
+
+	return s
+}
+
+// AddRepository creates a repository with a single commit on its default branch, containing the files.
+// Unset metadata is defaulted, e.g. the default branch is "main".
+func (s *Server) AddRepository(meta gitea.Repository, files map[string]string) {
+	s.t.Helper()
+
+	if meta.DefaultBranch == "" {
+		meta.DefaultBranch = defaultBranch
+	}
+	if meta.UpdatedAt.IsZero() {
+		meta.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	}
+	meta.CloneURL = s.URL + "/" + meta.FullName + ".git"
+	meta.HTMLURL = s.URL + "/" + meta.FullName
+
+	s.git.Create(meta.FullName+".git", meta.DefaultBranch, files)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	meta.ID = int64(len(s.repos) + 1)
+	owner, _, _ := strings.Cut(meta.FullName, "/")
+	if _, ok := s.owners[owner]; !ok {
+		s.owners[owner] = int64(len(s.owners) + 1)
+	}
+	s.repos[meta.FullName] = meta
+}
+
+// AddPullRequest adds an open pull request from the head branch to the default branch, without
+// checking that the branch exists, e.g. to fill pages of pull requests.
+func (s *Server) AddPullRequest(fullName, head string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pr := &gitea.PullRequest{Number: len(s.prs[fullName]) + 1, Title: head, State: "open"}
+	pr.Head.Ref = head
+	pr.Base.Ref = defaultBranch
+	s.prs[fullName] = append(s.prs[fullName], pr)
+}
+
+// PullRequests returns a copy of the pull requests of the repository.
+func (s *Server) PullRequests(fullName string) []gitea.PullRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prs := make([]gitea.PullRequest, 0, len(s.prs[fullName]))
+	for _, pr := range s.prs[fullName] {
+		prs = append(prs, *pr)
+	}
+	return prs
+}
+
+// BranchFile returns the content of a file on a branch of the repository, e.g. to verify a push.
+func (s *Server) BranchFile(fullName, branch, file string) string {
+	s.t.Helper()
+	return s.git.File(fullName+".git", branch, file)
+}
+
+func (s *Server) getAuthenticatedUser(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, gitea.User{ID: 1, Login: Login})
+}
+
+func (s *Server) getUser(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := r.PathValue("name")
+	id, ok := s.owners[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, "user redirect does not exist [name: "+name+"]")
+		return
+	}
+	writeJSON(w, http.StatusOK, gitea.User{ID: id, Login: name})
+}
+
+// searchRepositories matches the keyword against the repository name, or with topic=true
+// exactly against its topics.
+func (s *Server) searchRepositories(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query := r.URL.Query()
+	keyword := strings.ToLower(query.Get("q"))
+	uid, _ := strconv.ParseInt(query.Get("uid"), 10, 64)
+
+	var matches []gitea.Repository
+	for _, fullName := range slices.Sorted(maps.Keys(s.repos)) {
+		repo := s.repos[fullName]
+		owner, name, _ := strings.Cut(fullName, "/")
+		switch {
+		case uid != 0 && s.owners[owner] != uid:
+			continue
+		case keyword == "":
+		case query.Get("topic") == "true":
+			if !slices.Contains(repo.Topics, keyword) {
+				continue
+			}
+		case !strings.Contains(strings.ToLower(name), keyword):
+			continue
+		}
+		matches = append(matches, repo)
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(len(matches)))
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "data": paginate(r, matches, s.MaxResponseItems)})
+}
+
+func (s *Server) getRepository(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	repo, ok := s.repos[r.PathValue("owner")+"/"+r.PathValue("repo")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "The target couldn't be found.")
+		return
+	}
+	writeJSON(w, http.StatusOK, repo)
+}
+
+func (s *Server) listPullRequests(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := r.URL.Query().Get("state")
+	prs := []gitea.PullRequest{}
+	for _, pr := range s.prs[r.PathValue("owner")+"/"+r.PathValue("repo")] {
+		if state != "" && state != "all" && pr.State != state {
+			continue
+		}
+		prs = append(prs, *pr)
+	}
+	writeJSON(w, http.StatusOK, paginate(r, prs, s.MaxResponseItems))
+}
+
+func (s *Server) createPullRequest(w http.ResponseWriter, r *http.Request) {
+	var opts gitea.CreatePullRequestOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fullName := r.PathValue("owner") + "/" + r.PathValue("repo")
+	if _, ok := s.repos[fullName]; !ok {
+		writeError(w, http.StatusNotFound, "The target couldn't be found.")
+		return
+	}
+	if opts.Title == "" || !s.git.HasBranch(fullName+".git", opts.Head) ||
+		!s.git.HasBranch(fullName+".git", opts.Base) {
+		writeError(w, http.StatusUnprocessableEntity, "Invalid PullRequest")
+		return
+	}
+	for _, pr := range s.prs[fullName] {
+		if pr.State == "open" && pr.Head.Ref == opts.Head {
+			writeError(w, http.StatusConflict, "pull request already exists for these targets")
+			return
+		}
+	}
+
+	number := len(s.prs[fullName]) + 1
+	pr := &gitea.PullRequest{
+		Number:  number,
+		Title:   opts.Title,
+		Body:    opts.Body,
+		State:   "open",
+		HTMLURL: fmt.Sprintf("%s/%s/pulls/%d", s.URL, fullName, number),
+	}
+	pr.Head.Ref = opts.Head
+	pr.Base.Ref = opts.Base
+	s.prs[fullName] = append(s.prs[fullName], pr)
+
+	writeJSON(w, http.StatusCreated, pr)
+}
+
+func (s *Server) updatePullRequest(w http.ResponseWriter, r *http.Request) {
+	var opts gitea.UpdatePullRequestOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	number, _ := strconv.Atoi(r.PathValue("number"))
+	for _, pr := range s.prs[r.PathValue("owner")+"/"+r.PathValue("repo")] {
+		if pr.Number != number {
+			continue
+		}
+		if opts.Title != "" {
+			pr.Title = opts.Title
+		}
+		if opts.Body != "" {
+			pr.Body = opts.Body
+		}
+		writeJSON(w, http.StatusCreated, pr)
+		return
+	}
+	writeError(w, http.StatusNotFound, "The target couldn't be found.")
+}
+
+// paginate returns the page of items requested with the page and limit query parameters,
+// where the limit is capped at maxItems unless it is zero.
+func paginate[T any](r *http.Request, items []T, maxItems int) []T {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	page = max(page, 1)
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if maxItems > 0 {
+		limit = min(limit, maxItems)
+	}
+
+	start := min((page-1)*limit, len(items))
+	end := min(start+limit, len(items))
+	return append([]T{}, items[start:end]...)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
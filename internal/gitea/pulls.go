@@ -0,0 +1,123 @@
+package gitea
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// draftPrefix marks a pull request as work in progress, which is how Gitea and Forgejo toggle draft state.
+const draftPrefix = "WIP: "
+
+// wipPrefixes are the default work in progress prefixes of Gitea and Forgejo (WORK_IN_PROGRESS_PREFIXES).
+var wipPrefixes = []string{"WIP:", "[WIP]"}
+
+// PullRequest is a subset of the Gitea pull request resource.
+type PullRequest struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	State   string `json:"state"`
+	HTMLURL string `json:"html_url"`
+	Head    struct {
+		Ref string `json:"ref"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+}
+
+// FindPullRequest returns the open pull request from the branch, or nil if there is none.
+func (c *Client) FindPullRequest(ctx context.Context, fullName, branch string) (*PullRequest, error) {
+	params := url.Values{}
+	params.Set("state", "open")
+	params.Set("limit", strconv.Itoa(perPage))
+
+	// Pull requests cannot be filtered by head branch, so all open pull requests are listed
+	for page := 1; ; page++ {
+		params.Set("page", strconv.Itoa(page))
+
+		var prs []PullRequest
+		if err := c.do(ctx, http.MethodGet, "/repos/"+fullName+"/pulls?"+params.Encode(), nil, &prs); err != nil {
+			return nil, err
+		}
+		// The page size may be capped below perPage (MAX_RESPONSE_ITEMS), so only an empty page is the last
+		if len(prs) == 0 {
+			return nil, nil //nolint:nilnil // no pull request is not an error
+		}
+		for i := range prs {
+			if prs[i].Head.Ref == branch {
+				return &prs[i], nil
+			}
+		}
+	}
+}
+
+// CreatePullRequestOptions holds the fields for a new pull request.
+type CreatePullRequestOptions struct {
+	Title string `json:"title"`
+	Head  string `json:"head"`
+	Base  string `json:"base"`
+	Body  string `json:"body,omitempty"`
+}
+
+// CreatePullRequest opens a new pull request.
+func (c *Client) CreatePullRequest(
+	ctx context.Context,
+	fullName string,
+	opts CreatePullRequestOptions,
+) (*PullRequest, error) {
+	var pr PullRequest
+	if err := c.do(ctx, http.MethodPost, "/repos/"+fullName+"/pulls", opts, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// UpdatePullRequestOptions holds the fields to change on an existing pull request.
+type UpdatePullRequestOptions struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+}
+
+// UpdatePullRequest edits an existing pull request.
+func (c *Client) UpdatePullRequest(
+	ctx context.Context,
+	fullName string,
+	number int,
+	opts UpdatePullRequestOptions,
+) (*PullRequest, error) {
+	var pr PullRequest
+	path := "/repos/" + fullName + "/pulls/" + strconv.Itoa(number)
+	if err := c.do(ctx, http.MethodPatch, path, opts, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// IsDraft reports whether the title marks the pull request as work in progress.
+func IsDraft(title string) bool {
+	_, ok := trimDraftPrefix(title)
+	return ok
+}
+
+// DraftTitle adds or removes the "WIP: " prefix.
+func DraftTitle(title string, draft bool) string {
+	title, _ = trimDraftPrefix(title)
+	if draft {
+		return draftPrefix + title
+	}
+	return title
+}
+
+// trimDraftPrefix removes any work in progress prefix, which Gitea matches case-insensitively.
+func trimDraftPrefix(title string) (string, bool) {
+	for _, prefix := range wipPrefixes {
+		if len(title) >= len(prefix) && strings.EqualFold(title[:len(prefix)], prefix) {
+			return strings.TrimLeft(title[len(prefix):], " "), true
+		}
+	}
+	return title, false
+}
@@ -0,0 +1,78 @@
+package gitea
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Repository is a subset of the Gitea repository resource.
+type Repository struct {
+	ID            int64     `json:"id"`
+	FullName      string    `json:"full_name"`
+	DefaultBranch string    `json:"default_branch"`
+	CloneURL      string    `json:"clone_url"`
+	HTMLURL       string    `json:"html_url"`
+	Archived      bool      `json:"archived"`
+	Fork          bool      `json:"fork"`
+	Template      bool      `json:"template"`
+	Private       bool      `json:"private"`
+	Internal      bool      `json:"internal"`
+	Language      string    `json:"language"`
+	Topics        []string  `json:"topics"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// GetRepository fetches a single repository, e.g. "owner/repo".
+func (c *Client) GetRepository(ctx context.Context, fullName string) (*Repository, error) {
+	var r Repository
+	if err := c.do(ctx, http.MethodGet, "/repos/"+fullName, nil, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// SearchRepositoriesOptions filters the repositories returned by SearchRepositories.
+type SearchRepositoriesOptions struct {
+	Query   string // keyword, matched against the repository name
+	Topic   bool   // match the query exactly against the topics instead
+	OwnerID int64  // limit to repositories owned by this user or organization
+}
+
+// SearchRepositories lists all repositories matching the options, following pagination.
+func (c *Client) SearchRepositories(ctx context.Context, opts SearchRepositoriesOptions) ([]Repository, error) {
+	params := url.Values{}
+	params.Set("limit", strconv.Itoa(perPage))
+	params.Set("sort", "id")
+	params.Set("order", "asc")
+	if opts.Query != "" {
+		params.Set("q", opts.Query)
+	}
+	if opts.Topic {
+		params.Set("topic", "true")
+	}
+	if opts.OwnerID != 0 {
+		params.Set("uid", strconv.FormatInt(opts.OwnerID, 10))
+		params.Set("exclusive", "true")
+	}
+
+	var repos []Repository
+	for page := 1; ; page++ {
+		params.Set("page", strconv.Itoa(page))
+
+		var response struct {
+			OK   bool         `json:"ok"`
+			Data []Repository `json:"data"`
+		}
+		if err := c.do(ctx, http.MethodGet, "/repos/search?"+params.Encode(), nil, &response); err != nil {
+			return nil, err
+		}
+		// The page size may be capped below perPage (MAX_RESPONSE_ITEMS), so only an empty page is the last
+		if len(response.Data) == 0 {
+			return repos, nil
+		}
+		repos = append(repos, response.Data...)
+	}
+}
@@ -0,0 +1,135 @@
+package github_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fredrikaverpil/multipr/internal/github"
+	"github.com/fredrikaverpil/multipr/internal/github/githubtest"
+)
+
+// newTestClient creates an unthrottled client of the server.
+func newTestClient(server *githubtest.Server, token string) *github.Client {
+	return github.NewClient(server.URL, token, nil)
+}
+
+func TestClient_Repositories(t *testing.T) {
+	server := githubtest.NewServer(t)
+	server.AddRepository(github.Repository{FullName: "acme/api", Language: "Go"}, map[string]string{
+		"go.mod": "module example.com/api\n",
+	})
+	server.AddRepository(github.Repository{FullName: "acme/web", Topics: []string{"frontend"}}, map[string]string{
+		"package.json": "{}\n",
+	})
+	server.AddRepository(github.Repository{FullName: "octocat/dotfiles"}, nil)
+	server.AddRepository(github.Repository{FullName: "octocat/notes", Visibility: "private"}, nil)
+	server.AddUser("octocat")
+	client := newTestClient(server, githubtest.Token)
+
+	repo, err := client.GetRepository(t.Context(), "acme/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.DefaultBranch != "main" || repo.Language != "Go" || repo.CloneURL != server.URL+"/acme/api.git" {
+		t.Fatalf("unexpected repository: %+v", repo)
+	}
+
+	// Owners which are not organizations are listed as users, with private repositories of the authenticated user
+	for owner, want := range map[string]int{"acme": 2, "octocat": 2} {
+		repos, listErr := client.ListOwnerRepositories(t.Context(), owner)
+		if listErr != nil {
+			t.Fatal(listErr)
+		}
+		if len(repos) != want {
+			t.Fatalf("expected %d repositories of %s, got %d", want, owner, len(repos))
+		}
+	}
+
+	result, err := client.Search(t.Context(), github.SearchCode, "org:acme filename:go.mod", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TotalCount != 1 || result.Repositories[0].FullName != "acme/api" {
+		t.Fatalf("unexpected code search result: %+v", result)
+	}
+
+	result, err = client.Search(t.Context(), github.SearchRepositories, "topic:frontend", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TotalCount != 1 || result.Repositories[0].FullName != "acme/web" ||
+		result.Repositories[0].PushedAt.IsZero() {
+		t.Fatalf("unexpected repository search result: %+v", result)
+	}
+
+	_, err = client.GetRepository(t.Context(), "acme/missing")
+	if !github.IsNotFound(err) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestClient_PullRequestLifecycle(t *testing.T) {
+	server := githubtest.NewServer(t)
+	server.AddRepository(github.Repository{FullName: "acme/api"}, map[string]string{"README.md": "# api\n"})
+	client := newTestClient(server, githubtest.Token)
+	ctx := t.Context()
+
+	// The head branch must exist
+	_, err := client.CreatePullRequest(ctx, "acme/api", github.CreatePullRequestOptions{
+		Title: "chore: update", Head: "multipr/update", Base: "main",
+	})
+	var apiErr *github.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 422 {
+		t.Fatalf("expected validation error for a missing branch, got %v", err)
+	}
+
+	pr, err := client.FindPullRequest(ctx, "acme/api", "main")
+	if err != nil || pr != nil {
+		t.Fatalf("expected no pull request, got %+v, %v", pr, err)
+	}
+
+	pr, err = client.CreatePullRequest(ctx, "acme/api", github.CreatePullRequestOptions{
+		Title: "chore: update", Head: "main", Base: "main", Body: "body", Draft: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = client.AddAssignees(ctx, "acme/api", pr.Number, []string{githubtest.Login}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.UpdatePullRequest(ctx, "acme/api", pr.Number, github.UpdatePullRequestOptions{
+		Title: "chore: updated",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.SetPullRequestDraft(ctx, pr.NodeID, false); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := client.FindPullRequest(ctx, "acme/api", "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.Number != pr.Number || found.Title != "chore: updated" || found.Body != "body" || found.Draft {
+		t.Fatalf("unexpected pull request: %+v", found)
+	}
+	if len(found.Assignees) != 1 || found.Assignees[0].Login != githubtest.Login {
+		t.Fatalf("expected pull request to be assigned to %s, got %+v", githubtest.Login, found.Assignees)
+	}
+
+	var graphQLErr *github.GraphQLError
+	if err = client.SetPullRequestDraft(ctx, "PR_missing", true); !errors.As(err, &graphQLErr) {
+		t.Fatalf("expected GraphQL error, got %v", err)
+	}
+}
+
+func TestClient_Unauthorized(t *testing.T) {
+	server := githubtest.NewServer(t)
+	client := newTestClient(server, "wrong")
+
+	_, err := client.AuthenticatedUser(t.Context())
+	var apiErr *github.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 401 || apiErr.Message != "Bad credentials" {
+		t.Fatalf("expected unauthorized error, got %v", err)
+	}
+}
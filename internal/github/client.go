@@ -0,0 +1,260 @@
+// Package github is a minimal GitHub REST and GraphQL API client.
+//
+// https://docs.github.com/en/rest
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fredrikaverpil/multipr/internal/ratelimit"
+)
+
+const (
+	DefaultHost = "github.com"
+
+	perPage        = 100
+	requestTimeout = 30 * time.Second
+	apiVersion     = "2022-11-28"
+)
+
+// Client talks to a single GitHub instance.
+type Client struct {
+	baseURL    string
+	token      func() string
+	httpClient *http.Client
+	limiter    *ratelimit.Limiter
+}
+
+// NewClient creates a new client, where baseURL is the REST API root, e.g. "https://api.github.com".
+// Requests are throttled by the limiter, which should be shared by all clients of the same account
+// (or nil to not throttle, e.g. in tests).
+func NewClient(baseURL, token string, limiter *ratelimit.Limiter) *Client {
+	return NewClientWithTokenFunc(baseURL, func() string { return token }, limiter)
+}
+
+// NewClientWithTokenFunc creates a new client like NewClient, whose token is resolved by calling token once,
+// when it is first needed. This avoids resolving the token (e.g. with the GitHub CLI) for unused instances.
+func NewClientWithTokenFunc(baseURL string, token func() string, limiter *ratelimit.Limiter) *Client {
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		token:      sync.OnceValue(token),
+		httpClient: &http.Client{Timeout: requestTimeout},
+		limiter:    limiter,
+	}
+}
+
+// BaseURL returns the REST API root for the given host, e.g. "github.com".
+func BaseURL(host string) string {
+	if host == DefaultHost {
+		return "https://api.github.com"
+	}
+	return "https://" + host + "/api/v3"
+}
+
+// Token returns the token requests are authenticated with, or an empty string.
+func (c *Client) Token() string {
+	return c.token()
+}
+
+// WebURL returns the root of the web interface (and git remotes) of the instance.
+func (c *Client) WebURL() string {
+	if c.baseURL == "https://api.github.com" {
+		return "https://github.com"
+	}
+	return strings.TrimSuffix(c.baseURL, "/api/v3")
+}
+
+// graphQLURL returns the GraphQL endpoint, which is not below the REST root on GitHub Enterprise Server.
+func (c *Client) graphQLURL() string {
+	if base, ok := strings.CutSuffix(c.baseURL, "/api/v3"); ok {
+		return base + "/api/graphql"
+	}
+	return c.baseURL + "/graphql"
+}
+
+// APIError is returned when GitHub responds with a non-2xx status code.
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("github: %s %s failed with status %d: %s", e.Method, e.Path, e.StatusCode, e.Message)
+}
+
+// IsNotFound reports whether err is an *APIError with status 404.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// GraphQLError is returned when a GraphQL request responds with errors.
+type GraphQLError struct {
+	Messages []string
+}
+
+func (e *GraphQLError) Error() string {
+	return "github: graphql: " + strings.Join(e.Messages, "; ")
+}
+
+// User is a subset of the GitHub user resource.
+type User struct {
+	Login string `json:"login"`
+}
+
+// AuthenticatedUser returns the user the token belongs to.
+func (c *Client) AuthenticatedUser(ctx context.Context) (*User, error) {
+	var u User
+	if _, err := c.do(ctx, ratelimit.Core, http.MethodGet, "/user", nil, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (c *Client) do(
+	ctx context.Context,
+	category ratelimit.Category,
+	method, path string,
+	body, out any,
+) (http.Header, error) {
+	return c.request(ctx, category, method, c.baseURL+path, path, body, out)
+}
+
+// graphql runs a GraphQL query or mutation, decoding its data into out.
+func (c *Client) graphql(
+	ctx context.Context,
+	category ratelimit.Category,
+	query string,
+	variables map[string]any,
+	out any,
+) error {
+	payload := map[string]any{"query": query, "variables": variables}
+
+	var response struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if _, err := c.request(ctx, category, http.MethodPost, c.graphQLURL(), "/graphql", payload, &response); err != nil {
+		return err
+	}
+
+	if len(response.Errors) > 0 {
+		graphQLErr := &GraphQLError{}
+		for _, e := range response.Errors {
+			graphQLErr.Messages = append(graphQLErr.Messages, e.Message)
+		}
+		return graphQLErr
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(response.Data, out); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+	}
+	return nil
+}
+
+// request sends a request throttled by the limiter, retrying it when it is rate limited.
+func (c *Client) request(
+	ctx context.Context,
+	category ratelimit.Category,
+	method, url, path string,
+	body, out any,
+) (http.Header, error) {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return nil, fmt.Errorf("failed to encode request body: %w", err)
+		}
+	}
+
+	var header http.Header
+	err := c.limiter.Do(ctx, category, func() error {
+		var reqErr error
+		header, reqErr = c.send(ctx, category, method, url, path, data, out)
+		return reqErr
+	})
+	return header, err
+}
+
+func (c *Client) send(
+	ctx context.Context,
+	category ratelimit.Category,
+	method, url, path string,
+	data []byte,
+	out any,
+) (http.Header, error) {
+	var reqBody io.Reader
+	if data != nil {
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", apiVersion)
+	if data != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token := c.Token(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("github: %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	c.limiter.ObserveHeader(category, resp.Header)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &APIError{
+			Method:     method,
+			Path:       path,
+			StatusCode: resp.StatusCode,
+			Message:    errorMessage(respBody),
+		}
+		return nil, ratelimit.FromResponse(apiErr, resp.StatusCode, resp.Header, apiErr.Message, time.Now())
+	}
+
+	if out != nil && len(respBody) > 0 {
+		if err = json.Unmarshal(respBody, out); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+	}
+
+	return resp.Header, nil
+}
+
+// errorMessage returns the message of a GitHub error response, or the raw body.
+func errorMessage(body []byte) string {
+	var response struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &response); err == nil && response.Message != "" {
+		return response.Message
+	}
+	return strings.TrimSpace(string(body))
+}
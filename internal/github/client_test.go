@@ -0,0 +1,31 @@
+package github //nolint:testpackage // internal testing needed for unexported functions
+
+import (
+	"testing"
+)
+
+func TestClient_URLs(t *testing.T) {
+	tests := []struct {
+		baseURL string
+		web     string
+		graphql string
+	}{
+		{baseURL: BaseURL("github.com"), web: "https://github.com", graphql: "https://api.github.com/graphql"},
+		{
+			baseURL: BaseURL("ghe.example.com"),
+			web:     "https://ghe.example.com",
+			graphql: "https://ghe.example.com/api/graphql",
+		},
+		{baseURL: "http://127.0.0.1:8080", web: "http://127.0.0.1:8080", graphql: "http://127.0.0.1:8080/graphql"},
+	}
+
+	for _, tt := range tests {
+		c := NewClient(tt.baseURL, "", nil)
+		if got := c.WebURL(); got != tt.web {
+			t.Errorf("%s: expected web URL %s, got %s", tt.baseURL, tt.web, got)
+		}
+		if got := c.graphQLURL(); got != tt.graphql {
+			t.Errorf("%s: expected GraphQL URL %s, got %s", tt.baseURL, tt.graphql, got)
+		}
+	}
+}
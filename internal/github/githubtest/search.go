@@ -0,0 +1,152 @@
+package githubtest
+
+import (
+	"maps"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/shlex"
+
+	"github.com/fredrikaverpil/multipr/internal/github"
+)
+
+// searchQuery is a parsed search query. Only a small subset of the GitHub search syntax is supported:
+// keywords, and the owner, repo, topic, language, archived, fork and filename qualifiers.
+// Range qualifiers (size, pushed) are accepted but ignored.
+type searchQuery struct {
+	keywords   []string
+	qualifiers map[string][]string
+}
+
+func parseSearchQuery(q string) (*searchQuery, error) {
+	terms, err := shlex.Split(q)
+	if err != nil {
+		return nil, err
+	}
+
+	query := &searchQuery{qualifiers: make(map[string][]string)}
+	for _, term := range terms {
+		if name, value, ok := strings.Cut(term, ":"); ok {
+			switch name {
+			case "org", "user", "owner":
+				name = "owner"
+			case "size", "pushed", "in", "path", "is":
+				continue
+			}
+			query.qualifiers[name] = append(query.qualifiers[name], value)
+			continue
+		}
+		query.keywords = append(query.keywords, strings.ToLower(term))
+	}
+	return query, nil
+}
+
+// matchesRepository reports whether the repository matches the qualifiers of the query.
+func (q *searchQuery) matchesRepository(repo github.Repository) bool {
+	owner, _, _ := strings.Cut(repo.FullName, "/")
+	checks := map[string]func(string) bool{
+		"owner":    func(v string) bool { return strings.EqualFold(v, owner) },
+		"repo":     func(v string) bool { return strings.EqualFold(v, repo.FullName) },
+		"topic":    func(v string) bool { return slices.Contains(repo.Topics, v) },
+		"language": func(v string) bool { return strings.EqualFold(v, repo.Language) },
+		"archived": func(v string) bool { return v == strconv.FormatBool(repo.Archived) },
+		"fork":     func(v string) bool { return v == "true" || (v == "only") == repo.Fork }, // true includes forks
+	}
+	for name, check := range checks {
+		values := q.qualifiers[name]
+		if len(values) > 0 && !slices.ContainsFunc(values, check) {
+			return false
+		}
+	}
+	return true
+}
+
+// searchCode returns one item per matching file, where files match on their name (filename qualifier)
+// and on containing all keywords.
+func (s *Server) searchCode(w http.ResponseWriter, r *http.Request) {
+	query, err := parseSearchQuery(r.URL.Query().Get("q"))
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	type codeResult struct {
+		Name       string            `json:"name"`
+		Path       string            `json:"path"`
+		Repository github.Repository `json:"repository"`
+	}
+	var items []codeResult
+	for _, fullName := range slices.Sorted(maps.Keys(s.repos)) {
+		repo := s.repos[fullName]
+		if !query.matchesRepository(repo.meta) {
+			continue
+		}
+		for _, file := range slices.Sorted(maps.Keys(repo.files)) {
+			if names := query.qualifiers["filename"]; len(names) > 0 && !slices.Contains(names, fileName(file)) {
+				continue
+			}
+			content := strings.ToLower(repo.files[file])
+			if !allContained(query.keywords, content) {
+				continue
+			}
+			// Code search only returns a minimal repository
+			items = append(items, codeResult{
+				Name:       fileName(file),
+				Path:       file,
+				Repository: github.Repository{NodeID: repo.meta.NodeID, FullName: fullName, Fork: repo.meta.Fork},
+			})
+		}
+	}
+
+	writeSearchResult(w, r, items)
+}
+
+// searchRepositories returns the repositories with all keywords in their name.
+func (s *Server) searchRepositories(w http.ResponseWriter, r *http.Request) {
+	query, err := parseSearchQuery(r.URL.Query().Get("q"))
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []github.Repository
+	for _, fullName := range slices.Sorted(maps.Keys(s.repos)) {
+		repo := s.repos[fullName]
+		if query.matchesRepository(repo.meta) && allContained(query.keywords, strings.ToLower(fullName)) {
+			items = append(items, repo.meta)
+		}
+	}
+
+	writeSearchResult(w, r, items)
+}
+
+func writeSearchResult[T any](w http.ResponseWriter, r *http.Request, items []T) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"total_count":        len(items),
+		"incomplete_results": false,
+		"items":              paginate(r, items),
+	})
+}
+
+// fileName returns the base name of a file path in a repository.
+func fileName(file string) string {
+	return path.Base(file)
+}
+
+func allContained(keywords []string, s string) bool {
+	for _, keyword := range keywords {
+		if !strings.Contains(s, keyword) {
+			return false
+		}
+	}
+	return true
+}
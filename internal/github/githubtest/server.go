@@ -0,0 +1,401 @@
+// Package githubtest is an in-memory fake of the GitHub REST and GraphQL APIs. It also serves its
+// repositories over git smart HTTP (using `git http-backend`), so that searching, cloning, pushing
+// and opening pull requests can be exercised end-to-end without network access.
+package githubtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fredrikaverpil/multipr/internal/github"
+	"github.com/fredrikaverpil/multipr/internal/gittest"
+)
+
+const (
+	// Token is the only token accepted by the server.
+	Token = "githubtest-token"
+	// Login is the user the token belongs to.
+	Login = "octocat"
+
+	defaultBranch = "main"
+	perPage       = 30
+)
+
+// Server is a fake GitHub instance. Use its URL as the base URL of a github.Client.
+type Server struct {
+	URL string
+
+	t   testing.TB
+	git *gittest.Repos // bare repositories, <owner>/<repo>.git
+
+	mu      sync.Mutex
+	repos   map[string]*repository
+	users   map[string]bool // owners which are users rather than organizations
+	prs     map[string][]*github.PullRequest
+	nextNum int
+}
+
+type repository struct {
+	meta  github.Repository
+	files map[string]string // files of the initial commit, used by code search
+}
+
+// NewServer starts a fake GitHub instance, which is closed when the test ends.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+
+	s := &Server{
+		t:       t,
+		git:     gittest.New(t),
+		repos:   make(map[string]*repository),
+		users:   make(map[string]bool),
+		prs:     make(map[string][]*github.PullRequest),
+		nextNum: 1,
+	}
+
+	api := http.NewServeMux()
+	api.HandleFunc("GET /user", s.getUser)
+	api.HandleFunc("GET /repos/{owner}/{repo}", s.getRepository)
+	api.HandleFunc("GET /orgs/{owner}/repos", s.listOwnerRepositories)
+	api.HandleFunc("GET /users/{owner}/repos", s.listOwnerRepositories)
+	api.HandleFunc("GET /user/repos", s.listOwnerRepositories)
+	api.HandleFunc("GET /search/code", s.searchCode)
+	api.HandleFunc("GET /search/repositories", s.searchRepositories)
+	api.HandleFunc("GET /repos/{owner}/{repo}/pulls", s.listPullRequests)
+	api.HandleFunc("POST /repos/{owner}/{repo}/pulls", s.createPullRequest)
+	api.HandleFunc("GET /repos/{owner}/{repo}/pulls/{number}", s.getPullRequest)
+	api.HandleFunc("PATCH /repos/{owner}/{repo}/pulls/{number}", s.updatePullRequest)
+	api.HandleFunc("POST /repos/{owner}/{repo}/issues/{number}/assignees", s.addAssignees)
+	api.HandleFunc("POST /graphql", s.graphql)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if gittest.IsGitRequest(r) {
+			// Like private repositories, git requires the token, which git sends as the password
+			if _, password, ok := r.BasicAuth(); !ok || password != Token {
+				w.Header().Set("WWW-Authenticate", `Basic realm="GitHub"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			s.git.ServeHTTP(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer "+Token {
+			writeError(w, http.StatusUnauthorized, "Bad credentials")
+			return
+		}
+		w.Header().Set("X-Ratelimit-Remaining", "4999")
+		w.Header().Set("X-Ratelimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		api.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	s.URL = server.URL
+
+	return s
+}
+
+// AddRepository creates a repository with a single commit on its default branch, containing the files.
+// Unset metadata is defaulted, e.g. the default branch is "main".
+func (s *Server) AddRepository(meta github.Repository, files map[string]string) {
+	s.t.Helper()
+
+	if meta.DefaultBranch == "" {
+		meta.DefaultBranch = defaultBranch
+	}
+	if meta.Visibility == "" {
+		meta.Visibility = "public"
+	}
+	if meta.PushedAt.IsZero() {
+		meta.PushedAt = time.Now().UTC().Truncate(time.Second)
+	}
+	meta.NodeID = "R_" + meta.FullName
+	meta.CloneURL = s.URL + "/" + meta.FullName + ".git"
+	meta.HTMLURL = s.URL + "/" + meta.FullName
+
+	s.git.Create(meta.FullName+".git", meta.DefaultBranch, files)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.repos[meta.FullName] = &repository{meta: meta, files: files}
+}
+
+// AddUser marks the owner as a user, so that listing its repositories as an organization fails.
+func (s *Server) AddUser(login string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[login] = true
+}
+
+// PullRequests returns a copy of the pull requests of the repository.
+func (s *Server) PullRequests(fullName string) []github.PullRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prs := make([]github.PullRequest, 0, len(s.prs[fullName]))
+	for _, pr := range s.prs[fullName] {
+		prs = append(prs, *pr)
+	}
+	return prs
+}
+
+// BranchFile returns the content of a file on a branch of the repository, e.g. to verify a push.
+func (s *Server) BranchFile(fullName, branch, file string) string {
+	s.t.Helper()
+	return s.git.File(fullName+".git", branch, file)
+}
+
+func (s *Server) getUser(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, github.User{Login: Login})
+}
+
+func (s *Server) getRepository(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	repo, ok := s.repos[r.PathValue("owner")+"/"+r.PathValue("repo")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	writeJSON(w, http.StatusOK, repo.meta)
+}
+
+func (s *Server) listOwnerRepositories(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The repositories of the authenticated user include private ones, whereas other listings of users do not
+	owner, authenticated := r.PathValue("owner"), r.URL.Path == "/user/repos"
+	if authenticated {
+		owner = Login
+	}
+	isUser := s.users[owner] || authenticated
+	if strings.HasPrefix(r.URL.Path, "/orgs/") == isUser {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	var repos []github.Repository
+	for _, fullName := range slices.Sorted(maps.Keys(s.repos)) {
+		meta := s.repos[fullName].meta
+		if !strings.HasPrefix(fullName, owner+"/") {
+			continue
+		}
+		if isUser && !authenticated && meta.Visibility == "private" {
+			continue
+		}
+		repos = append(repos, meta)
+	}
+	writeJSON(w, http.StatusOK, paginate(r, repos))
+}
+
+func (s *Server) listPullRequests(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	owner := r.PathValue("owner")
+	fullName := owner + "/" + r.PathValue("repo")
+	state := r.URL.Query().Get("state")
+	head := r.URL.Query().Get("head")
+
+	prs := []github.PullRequest{}
+	for _, pr := range s.prs[fullName] {
+		if state != "" && state != "all" && pr.State != state {
+			continue
+		}
+		if head != "" && head != owner+":"+pr.Head.Ref {
+			continue
+		}
+		prs = append(prs, *pr)
+	}
+	writeJSON(w, http.StatusOK, prs)
+}
+
+func (s *Server) createPullRequest(w http.ResponseWriter, r *http.Request) {
+	var opts github.CreatePullRequestOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		writeError(w, http.StatusBadRequest, "Problems parsing JSON")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fullName := r.PathValue("owner") + "/" + r.PathValue("repo")
+	if _, ok := s.repos[fullName]; !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if opts.Title == "" || !s.git.HasBranch(fullName+".git", opts.Head) ||
+		!s.git.HasBranch(fullName+".git", opts.Base) {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+		return
+	}
+	for _, pr := range s.prs[fullName] {
+		if pr.State == "open" && pr.Head.Ref == opts.Head {
+			writeError(w, http.StatusUnprocessableEntity, "A pull request already exists for "+opts.Head)
+			return
+		}
+	}
+
+	pr := &github.PullRequest{
+		NodeID:  fmt.Sprintf("PR_%d", s.nextNum),
+		Number:  s.nextNum,
+		Title:   opts.Title,
+		Body:    opts.Body,
+		State:   "open",
+		Draft:   opts.Draft,
+		HTMLURL: fmt.Sprintf("%s/%s/pull/%d", s.URL, fullName, s.nextNum),
+	}
+	pr.Head.Ref = opts.Head
+	pr.Base.Ref = opts.Base
+	s.nextNum++
+	s.prs[fullName] = append(s.prs[fullName], pr)
+
+	writeJSON(w, http.StatusCreated, pr)
+}
+
+func (s *Server) getPullRequest(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pr := s.findPullRequest(r)
+	if pr == nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	writeJSON(w, http.StatusOK, pr)
+}
+
+func (s *Server) updatePullRequest(w http.ResponseWriter, r *http.Request) {
+	var opts github.UpdatePullRequestOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		writeError(w, http.StatusBadRequest, "Problems parsing JSON")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pr := s.findPullRequest(r)
+	if pr == nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if opts.Title != "" {
+		pr.Title = opts.Title
+	}
+	if opts.Body != "" {
+		pr.Body = opts.Body
+	}
+	writeJSON(w, http.StatusOK, pr)
+}
+
+func (s *Server) addAssignees(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Assignees []string `json:"assignees"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "Problems parsing JSON")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pr := s.findPullRequest(r)
+	if pr == nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	for _, login := range body.Assignees {
+		pr.Assignees = append(pr.Assignees, github.User{Login: login})
+	}
+	writeJSON(w, http.StatusCreated, pr)
+}
+
+// graphql supports the mutations which change the draft state of a pull request.
+func (s *Server) graphql(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Query     string `json:"query"`
+		Variables struct {
+			ID string `json:"id"`
+		} `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "Problems parsing JSON")
+		return
+	}
+
+	var draft bool
+	switch {
+	case strings.Contains(request.Query, "convertPullRequestToDraft"):
+		draft = true
+	case strings.Contains(request.Query, "markPullRequestReadyForReview"):
+		draft = false
+	default:
+		writeGraphQLError(w, "unsupported query")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, prs := range s.prs {
+		for _, pr := range prs {
+			if pr.NodeID == request.Variables.ID {
+				pr.Draft = draft
+				writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{}})
+				return
+			}
+		}
+	}
+	writeGraphQLError(w, fmt.Sprintf("Could not resolve to a node with the global id of '%s'", request.Variables.ID))
+}
+
+func (s *Server) findPullRequest(r *http.Request) *github.PullRequest {
+	fullName := r.PathValue("owner") + "/" + r.PathValue("repo")
+	number, _ := strconv.Atoi(r.PathValue("number"))
+	for _, pr := range s.prs[fullName] {
+		if pr.Number == number {
+			return pr
+		}
+	}
+	return nil
+}
+
+// paginate returns the page of items requested with the page and per_page query parameters.
+func paginate[T any](r *http.Request, items []T) []T {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	page = max(page, 1)
+	size, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+	if size <= 0 {
+		size = perPage
+	}
+
+	start := min((page-1)*size, len(items))
+	end := min(start+size, len(items))
+	return append([]T{}, items[start:end]...)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
+
+func writeGraphQLError(w http.ResponseWriter, message string) {
+	writeJSON(w, http.StatusOK, map[string]any{"errors": []map[string]string{{"message": message}}})
+}
@@ -0,0 +1,119 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/fredrikaverpil/multipr/internal/ratelimit"
+)
+
+// PullRequest is a subset of the GitHub pull request resource.
+type PullRequest struct {
+	NodeID  string `json:"node_id"`
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	State   string `json:"state"`
+	Draft   bool   `json:"draft"`
+	HTMLURL string `json:"html_url"`
+	Head    struct {
+		Ref string `json:"ref"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+	Assignees []User `json:"assignees"`
+}
+
+// FindPullRequest returns the open pull request from the branch of the repository itself,
+// or nil if there is none.
+func (c *Client) FindPullRequest(ctx context.Context, fullName, branch string) (*PullRequest, error) {
+	owner, _, _ := strings.Cut(fullName, "/")
+	params := url.Values{}
+	params.Set("state", "open")
+	params.Set("head", owner+":"+branch)
+
+	var prs []PullRequest
+	path := "/repos/" + fullName + "/pulls?" + params.Encode()
+	if _, err := c.do(ctx, ratelimit.Core, http.MethodGet, path, nil, &prs); err != nil {
+		return nil, err
+	}
+	if len(prs) == 0 {
+		return nil, nil //nolint:nilnil // no pull request is not an error
+	}
+	return &prs[0], nil
+}
+
+// GetPullRequest fetches a single pull request.
+func (c *Client) GetPullRequest(ctx context.Context, fullName string, number int) (*PullRequest, error) {
+	var pr PullRequest
+	path := "/repos/" + fullName + "/pulls/" + strconv.Itoa(number)
+	if _, err := c.do(ctx, ratelimit.Core, http.MethodGet, path, nil, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// CreatePullRequestOptions holds the fields for a new pull request.
+type CreatePullRequestOptions struct {
+	Title string `json:"title"`
+	Head  string `json:"head"`
+	Base  string `json:"base"`
+	Body  string `json:"body"`
+	Draft bool   `json:"draft"`
+}
+
+// CreatePullRequest opens a new pull request.
+func (c *Client) CreatePullRequest(
+	ctx context.Context,
+	fullName string,
+	opts CreatePullRequestOptions,
+) (*PullRequest, error) {
+	var pr PullRequest
+	if _, err := c.do(ctx, ratelimit.Content, http.MethodPost, "/repos/"+fullName+"/pulls", opts, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// UpdatePullRequestOptions holds the fields to change on an existing pull request.
+type UpdatePullRequestOptions struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+}
+
+// UpdatePullRequest edits an existing pull request.
+func (c *Client) UpdatePullRequest(
+	ctx context.Context,
+	fullName string,
+	number int,
+	opts UpdatePullRequestOptions,
+) (*PullRequest, error) {
+	var pr PullRequest
+	path := "/repos/" + fullName + "/pulls/" + strconv.Itoa(number)
+	if _, err := c.do(ctx, ratelimit.Content, http.MethodPatch, path, opts, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// AddAssignees assigns users to a pull request (or issue).
+func (c *Client) AddAssignees(ctx context.Context, fullName string, number int, logins []string) error {
+	body := map[string][]string{"assignees": logins}
+	path := "/repos/" + fullName + "/issues/" + strconv.Itoa(number) + "/assignees"
+	_, err := c.do(ctx, ratelimit.Content, http.MethodPost, path, body, nil)
+	return err
+}
+
+// SetPullRequestDraft converts a pull request to a draft, or marks it as ready for review.
+// The REST API cannot change the draft state, so this uses GraphQL with the node ID of the pull request.
+func (c *Client) SetPullRequestDraft(ctx context.Context, nodeID string, draft bool) error {
+	mutation := `mutation($id: ID!) { markPullRequestReadyForReview(input: {pullRequestId: $id}) { clientMutationId } }`
+	if draft {
+		mutation = `mutation($id: ID!) { convertPullRequestToDraft(input: {pullRequestId: $id}) { clientMutationId } }`
+	}
+	return c.graphql(ctx, ratelimit.Content, mutation, map[string]any{"id": nodeID}, nil)
+}
@@ -0,0 +1,140 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fredrikaverpil/multipr/internal/ratelimit"
+)
+
+// Repository is a subset of the GitHub repository resource.
+type Repository struct {
+	NodeID        string    `json:"node_id,omitempty"`
+	FullName      string    `json:"full_name"`
+	DefaultBranch string    `json:"default_branch,omitempty"`
+	CloneURL      string    `json:"clone_url,omitempty"`
+	HTMLURL       string    `json:"html_url,omitempty"`
+	Archived      bool      `json:"archived"`
+	Disabled      bool      `json:"disabled"`
+	Fork          bool      `json:"fork"`
+	IsTemplate    bool      `json:"is_template"`
+	Visibility    string    `json:"visibility,omitempty"`
+	Language      string    `json:"language,omitempty"`
+	Topics        []string  `json:"topics,omitempty"`
+	PushedAt      time.Time `json:"pushed_at,omitzero"`
+}
+
+// GetRepository fetches a single repository, e.g. "owner/repo".
+func (c *Client) GetRepository(ctx context.Context, fullName string) (*Repository, error) {
+	var r Repository
+	if _, err := c.do(ctx, ratelimit.Core, http.MethodGet, "/repos/"+fullName, nil, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// ListOwnerRepositories lists all repositories of an organization or user, following pagination.
+// Unlike a search, this does not depend on the search index.
+func (c *Client) ListOwnerRepositories(ctx context.Context, owner string) ([]Repository, error) {
+	// https://docs.github.com/en/rest/repos/repos#list-organization-repositories
+	repos, err := c.listRepositories(ctx, "/orgs/"+url.PathEscape(owner)+"/repos", url.Values{"type": {"all"}})
+	if !IsNotFound(err) {
+		return repos, err
+	}
+
+	// Not an organization. Only the authenticated user's own listing includes their private repositories
+	// https://docs.github.com/en/rest/repos/repos#list-repositories-for-the-authenticated-user
+	if user, userErr := c.AuthenticatedUser(ctx); userErr == nil && strings.EqualFold(user.Login, owner) {
+		return c.listRepositories(ctx, "/user/repos", url.Values{"affiliation": {"owner"}})
+	}
+
+	// https://docs.github.com/en/rest/repos/repos#list-repositories-for-a-user
+	return c.listRepositories(ctx, "/users/"+url.PathEscape(owner)+"/repos", url.Values{"type": {"owner"}})
+}
+
+func (c *Client) listRepositories(ctx context.Context, path string, params url.Values) ([]Repository, error) {
+	params.Set("per_page", strconv.Itoa(perPage))
+
+	var repos []Repository
+	for page := 1; ; page++ {
+		params.Set("page", strconv.Itoa(page))
+
+		var batch []Repository
+		if _, err := c.do(ctx, ratelimit.Core, http.MethodGet, path+"?"+params.Encode(), nil, &batch); err != nil {
+			return nil, err
+		}
+		repos = append(repos, batch...)
+
+		if len(batch) < perPage {
+			return repos, nil
+		}
+	}
+}
+
+// SearchEndpoint is a search API endpoint.
+type SearchEndpoint string
+
+const (
+	SearchCode         SearchEndpoint = "search/code"
+	SearchRepositories SearchEndpoint = "search/repositories"
+)
+
+// SearchResult is a single page of search results.
+type SearchResult struct {
+	TotalCount        int
+	IncompleteResults bool
+	Items             int // number of items on the page
+	// Repositories of the items. Code search returns a minimal repository without metadata.
+	Repositories []Repository
+}
+
+// Search fetches a single page of search results for the raw query, e.g. `org:myorg filename:go.mod`.
+// https://docs.github.com/en/rest/search/search
+func (c *Client) Search(ctx context.Context, endpoint SearchEndpoint, query string, page int) (*SearchResult, error) {
+	if endpoint != SearchCode && endpoint != SearchRepositories {
+		return nil, fmt.Errorf("unsupported GitHub search endpoint: %s", endpoint)
+	}
+
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("per_page", strconv.Itoa(perPage))
+	params.Set("page", strconv.Itoa(page))
+
+	var response struct {
+		TotalCount        int               `json:"total_count"`
+		IncompleteResults bool              `json:"incomplete_results"`
+		Items             []json.RawMessage `json:"items"`
+	}
+	path := "/" + string(endpoint) + "?" + params.Encode()
+	if _, err := c.do(ctx, ratelimit.Search, http.MethodGet, path, nil, &response); err != nil {
+		return nil, err
+	}
+
+	result := &SearchResult{
+		TotalCount:        response.TotalCount,
+		IncompleteResults: response.IncompleteResults,
+		Items:             len(response.Items),
+	}
+	for _, raw := range response.Items {
+		// Code search items hold the repository they were found in, repository search items are repositories
+		var item struct {
+			Repository
+			CodeRepository *Repository `json:"repository"`
+		}
+		if err := json.Unmarshal(raw, &item); err != nil {
+			return nil, fmt.Errorf("failed to parse search result: %w", err)
+		}
+		if item.CodeRepository != nil {
+			result.Repositories = append(result.Repositories, *item.CodeRepository)
+			continue
+		}
+		result.Repositories = append(result.Repositories, item.Repository)
+	}
+	return result, nil
+}
@@ -0,0 +1,120 @@
+package github //nolint:testpackage // internal testing needed for the page size
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestClient_ListOwnerRepositories(t *testing.T) {
+	// acme is an organization with more than a page of repositories, octocat the authenticated user with exactly
+	// two pages (including private repositories), and hubot another user
+	owners := map[string]string{"/orgs/acme/repos": "acme", "/user/repos": "octocat", "/users/hubot/repos": "hubot"}
+	counts := map[string]int{"/orgs/acme/repos": perPage + 1, "/user/repos": 2 * perPage, "/users/hubot/repos": 1}
+
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		mu.Lock()
+		requests = append(requests, fmt.Sprintf("%s type=%s affiliation=%s page=%s",
+			r.URL.Path, query.Get("type"), query.Get("affiliation"), query.Get("page")))
+		mu.Unlock()
+
+		if r.URL.Path == "/user" {
+			_ = json.NewEncoder(w).Encode(User{Login: "octocat"})
+			return
+		}
+		count, ok := counts[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+			return
+		}
+		page, _ := strconv.Atoi(query.Get("page"))
+		size, _ := strconv.Atoi(query.Get("per_page"))
+		repos := []Repository{}
+		for i := (page - 1) * size; i < min(page*size, count); i++ {
+			repo := Repository{FullName: fmt.Sprintf("%s/repo-%d", owners[r.URL.Path], i), Visibility: "public"}
+			if i == 0 && r.URL.Path == "/user/repos" {
+				repo.Visibility = "private"
+			}
+			repos = append(repos, repo)
+		}
+		_ = json.NewEncoder(w).Encode(repos)
+	}))
+	t.Cleanup(server.Close)
+	client := NewClient(server.URL, "token", nil)
+
+	tests := []struct {
+		owner        string
+		wantRepos    int
+		wantPrivate  bool
+		wantRequests []string
+	}{
+		{
+			owner:     "acme",
+			wantRepos: perPage + 1,
+			wantRequests: []string{
+				"/orgs/acme/repos type=all affiliation= page=1",
+				"/orgs/acme/repos type=all affiliation= page=2",
+			},
+		},
+		{
+			// The authenticated user is listed with their private repositories, until a page is not full
+			owner:       "octocat",
+			wantRepos:   2 * perPage,
+			wantPrivate: true,
+			wantRequests: []string{
+				"/orgs/octocat/repos type=all affiliation= page=1",
+				"/user type= affiliation= page=",
+				"/user/repos type= affiliation=owner page=1",
+				"/user/repos type= affiliation=owner page=2",
+				"/user/repos type= affiliation=owner page=3",
+			},
+		},
+		{
+			owner:     "hubot",
+			wantRepos: 1,
+			wantRequests: []string{
+				"/orgs/hubot/repos type=all affiliation= page=1",
+				"/user type= affiliation= page=",
+				"/users/hubot/repos type=owner affiliation= page=1",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.owner, func(t *testing.T) {
+			requests = nil
+			repos, err := client.ListOwnerRepositories(t.Context(), tt.owner)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(repos) != tt.wantRepos {
+				t.Fatalf("expected %d repositories, got %d", tt.wantRepos, len(repos))
+			}
+			seen := make(map[string]bool, len(repos))
+			for _, repo := range repos {
+				seen[repo.FullName] = true
+			}
+			if len(seen) != len(repos) {
+				t.Fatalf("expected each repository once, got %d distinct of %d", len(seen), len(repos))
+			}
+			if tt.wantPrivate && repos[0].Visibility != "private" {
+				t.Fatalf("expected the private repository to be listed, got %+v", repos[0])
+			}
+			if fmt.Sprint(requests) != fmt.Sprint(tt.wantRequests) {
+				t.Fatalf("expected requests %v, got %v", tt.wantRequests, requests)
+			}
+		})
+	}
+
+	// Owners which are neither organizations nor users are not found
+	if _, err := client.ListOwnerRepositories(t.Context(), "missing"); !IsNotFound(err) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
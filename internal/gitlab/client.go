@@ -0,0 +1,274 @@
+// Package gitlab is a minimal GitLab REST API (v4) client.
+//
+// https://docs.gitlab.com/api/rest/
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	perPage        = 100
+	requestTimeout = 30 * time.Second
+	draftPrefix    = "Draft: "
+)
+
+// Client talks to a single GitLab instance.
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a new client, where baseURL is the API root,
+// e.g. "https://gitlab.com/api/v4".
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		token:      token,
+		httpClient: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// BaseURL returns the API root for the given host, e.g. "gitlab.example.com".
+func BaseURL(host string) string {
+	return "https://" + host + "/api/v4"
+}
+
+// Token returns the token requests are authenticated with, or an empty string.
+func (c *Client) Token() string {
+	return c.token
+}
+
+// APIError is returned when GitLab responds with a non-2xx status code.
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("gitlab: %s %s failed with status %d: %s", e.Method, e.Path, e.StatusCode, e.Message)
+}
+
+// Project is a subset of the GitLab project resource.
+type Project struct {
+	ID                int       `json:"id"`
+	PathWithNamespace string    `json:"path_with_namespace"`
+	DefaultBranch     string    `json:"default_branch"`
+	HTTPURLToRepo     string    `json:"http_url_to_repo"`
+	WebURL            string    `json:"web_url"`
+	Archived          bool      `json:"archived"`
+	Visibility        string    `json:"visibility"`
+	Topics            []string  `json:"topics"`
+	LastActivityAt    time.Time `json:"last_activity_at"`
+	ForkedFromProject *struct {
+		ID int `json:"id"`
+	} `json:"forked_from_project"`
+}
+
+// MergeRequest is a subset of the GitLab merge request resource.
+type MergeRequest struct {
+	IID          int    `json:"iid"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	SourceBranch string `json:"source_branch"`
+	TargetBranch string `json:"target_branch"`
+	State        string `json:"state"`
+	Draft        bool   `json:"draft"`
+	WebURL       string `json:"web_url"`
+}
+
+// ListProjectsOptions filters the projects returned by ListProjects.
+type ListProjectsOptions struct {
+	Group  string
+	Topic  string
+	Search string
+	// Membership limits the projects to those the user is a member of. It is implied without a group,
+	// as every public project of the instance would be listed otherwise.
+	Membership bool
+	// Archived lists only archived (true) or non-archived (false) projects, or both if nil
+	Archived *bool
+}
+
+// ListProjects lists all projects matching the options, following pagination.
+func (c *Client) ListProjects(ctx context.Context, opts ListProjectsOptions) ([]Project, error) {
+	path := "/projects"
+	params := url.Values{}
+	if opts.Archived != nil {
+		params.Set("archived", strconv.FormatBool(*opts.Archived))
+	}
+	params.Set("order_by", "id")
+	params.Set("sort", "asc")
+	if opts.Group != "" {
+		path = "/groups/" + url.PathEscape(opts.Group) + "/projects"
+		params.Set("include_subgroups", "true")
+	}
+	if opts.Topic != "" {
+		params.Set("topic", opts.Topic)
+	}
+	if opts.Search != "" {
+		params.Set("search", opts.Search)
+	}
+	if opts.Membership || opts.Group == "" {
+		params.Set("membership", "true")
+	}
+
+	var projects []Project
+	page := 1
+	for page > 0 {
+		params.Set("per_page", strconv.Itoa(perPage))
+		params.Set("page", strconv.Itoa(page))
+
+		var batch []Project
+		header, err := c.do(ctx, http.MethodGet, path+"?"+params.Encode(), nil, &batch)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, batch...)
+
+		// GitLab omits X-Next-Page on the last page
+		page, _ = strconv.Atoi(header.Get("X-Next-Page"))
+	}
+
+	return projects, nil
+}
+
+// GetProject fetches a single project by its full path, e.g. "group/subgroup/project".
+func (c *Client) GetProject(ctx context.Context, project string) (*Project, error) {
+	var p Project
+	if _, err := c.do(ctx, http.MethodGet, "/projects/"+url.PathEscape(project), nil, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// FindMergeRequest returns the open merge request for the source branch, or nil if there is none.
+func (c *Client) FindMergeRequest(ctx context.Context, project, sourceBranch string) (*MergeRequest, error) {
+	params := url.Values{}
+	params.Set("source_branch", sourceBranch)
+	params.Set("state", "opened")
+
+	var mrs []MergeRequest
+	path := "/projects/" + url.PathEscape(project) + "/merge_requests?" + params.Encode()
+	if _, err := c.do(ctx, http.MethodGet, path, nil, &mrs); err != nil {
+		return nil, err
+	}
+	if len(mrs) == 0 {
+		return nil, nil //nolint:nilnil // no merge request is not an error
+	}
+	return &mrs[0], nil
+}
+
+// CreateMergeRequestOptions holds the fields for a new merge request.
+type CreateMergeRequestOptions struct {
+	SourceBranch string `json:"source_branch"`
+	TargetBranch string `json:"target_branch"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+}
+
+// CreateMergeRequest opens a new merge request.
+func (c *Client) CreateMergeRequest(
+	ctx context.Context,
+	project string,
+	opts CreateMergeRequestOptions,
+) (*MergeRequest, error) {
+	var mr MergeRequest
+	path := "/projects/" + url.PathEscape(project) + "/merge_requests"
+	if _, err := c.do(ctx, http.MethodPost, path, opts, &mr); err != nil {
+		return nil, err
+	}
+	return &mr, nil
+}
+
+// UpdateMergeRequestOptions holds the fields to change on an existing merge request.
+type UpdateMergeRequestOptions struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// UpdateMergeRequest edits an existing merge request.
+func (c *Client) UpdateMergeRequest(
+	ctx context.Context,
+	project string,
+	iid int,
+	opts UpdateMergeRequestOptions,
+) (*MergeRequest, error) {
+	var mr MergeRequest
+	path := "/projects/" + url.PathEscape(project) + "/merge_requests/" + strconv.Itoa(iid)
+	if _, err := c.do(ctx, http.MethodPut, path, opts, &mr); err != nil {
+		return nil, err
+	}
+	return &mr, nil
+}
+
+// DraftTitle adds or removes the "Draft: " prefix, which is how GitLab toggles draft state.
+func DraftTitle(title string, draft bool) string {
+	title = strings.TrimPrefix(title, draftPrefix)
+	if draft {
+		return draftPrefix + title
+	}
+	return title
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) (http.Header, error) {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request body: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Private-Token", c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("gitlab: %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &APIError{
+			Method:     method,
+			Path:       path,
+			StatusCode: resp.StatusCode,
+			Message:    strings.TrimSpace(string(data)),
+		}
+	}
+
+	if out != nil {
+		if err = json.Unmarshal(data, out); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+	}
+
+	return resp.Header, nil
+}
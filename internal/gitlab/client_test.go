@@ -0,0 +1,278 @@
+package gitlab //nolint:testpackage // internal testing needed for unexported fields
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeGitLab is a tiny in-memory stand-in for the GitLab REST API.
+type fakeGitLab struct {
+	mu         sync.Mutex
+	projects   []Project
+	mrs        map[string][]MergeRequest
+	membership []bool // whether each project listing was limited to memberships
+}
+
+func newFakeGitLab(t *testing.T, projects []Project) (*fakeGitLab, *Client) {
+	t.Helper()
+
+	fake := &fakeGitLab{projects: projects, mrs: map[string][]MergeRequest{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v4/groups/{group}/projects", fake.listProjects)
+	mux.HandleFunc("GET /api/v4/projects", fake.listProjects)
+	mux.HandleFunc("GET /api/v4/projects/{project}", fake.getProject)
+	mux.HandleFunc("GET /api/v4/projects/{project}/merge_requests", fake.listMergeRequests)
+	mux.HandleFunc("POST /api/v4/projects/{project}/merge_requests", fake.createMergeRequest)
+	mux.HandleFunc("PUT /api/v4/projects/{project}/merge_requests/{iid}", fake.updateMergeRequest)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Private-Token") != "secret" {
+			http.Error(w, `{"message":"401 Unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return fake, NewClient(server.URL+"/api/v4", "secret")
+}
+
+func (f *fakeGitLab) listProjects(w http.ResponseWriter, r *http.Request) {
+	group := r.PathValue("group")
+	topic := r.URL.Query().Get("topic")
+	archived := r.URL.Query().Get("archived")
+
+	f.mu.Lock()
+	f.membership = append(f.membership, r.URL.Query().Get("membership") == "true")
+	f.mu.Unlock()
+
+	var matches []Project
+	for _, p := range f.projects {
+		if group != "" && !strings.HasPrefix(p.PathWithNamespace, group+"/") {
+			continue
+		}
+		if topic != "" && !slices.Contains(p.Topics, topic) {
+			continue
+		}
+		if archived != "" && strconv.FormatBool(p.Archived) != archived {
+			continue
+		}
+		matches = append(matches, p)
+	}
+
+	// Paginate with one project per page to exercise X-Next-Page handling
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	var batch []Project
+	if page <= len(matches) {
+		batch = matches[page-1 : page]
+	}
+	if page < len(matches) {
+		w.Header().Set("X-Next-Page", strconv.Itoa(page+1))
+	}
+	writeJSON(w, batch)
+}
+
+func (f *fakeGitLab) getProject(w http.ResponseWriter, r *http.Request) {
+	for _, p := range f.projects {
+		if p.PathWithNamespace == r.PathValue("project") {
+			writeJSON(w, p)
+			return
+		}
+	}
+	http.Error(w, `{"message":"404 Project Not Found"}`, http.StatusNotFound)
+}
+
+func (f *fakeGitLab) listMergeRequests(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var matches []MergeRequest
+	for _, mr := range f.mrs[r.PathValue("project")] {
+		if mr.SourceBranch == r.URL.Query().Get("source_branch") && mr.State == "opened" {
+			matches = append(matches, mr)
+		}
+	}
+	writeJSON(w, matches)
+}
+
+func (f *fakeGitLab) createMergeRequest(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var opts CreateMergeRequestOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	project := r.PathValue("project")
+	mr := MergeRequest{
+		IID:          len(f.mrs[project]) + 1,
+		Title:        opts.Title,
+		Description:  opts.Description,
+		SourceBranch: opts.SourceBranch,
+		TargetBranch: opts.TargetBranch,
+		State:        "opened",
+		Draft:        strings.HasPrefix(opts.Title, draftPrefix),
+	}
+	f.mrs[project] = append(f.mrs[project], mr)
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, mr)
+}
+
+func (f *fakeGitLab) updateMergeRequest(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var opts UpdateMergeRequestOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	iid, _ := strconv.Atoi(r.PathValue("iid"))
+	mrs := f.mrs[r.PathValue("project")]
+	for i := range mrs {
+		if mrs[i].IID == iid {
+			mrs[i].Title = opts.Title
+			mrs[i].Description = opts.Description
+			mrs[i].Draft = strings.HasPrefix(opts.Title, draftPrefix)
+			writeJSON(w, mrs[i])
+			return
+		}
+	}
+	http.Error(w, `{"message":"404 Not found"}`, http.StatusNotFound)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func TestListProjects_GroupAndTopic(t *testing.T) {
+	_, client := newFakeGitLab(t, []Project{
+		{ID: 1, PathWithNamespace: "platform/api", Topics: []string{"go"}},
+		{ID: 2, PathWithNamespace: "platform/infra/terraform", Topics: []string{"go"}},
+		{ID: 3, PathWithNamespace: "platform/web", Topics: []string{"typescript"}},
+		{ID: 4, PathWithNamespace: "other/tool", Topics: []string{"go"}},
+	})
+
+	projects, err := client.ListProjects(t.Context(), ListProjectsOptions{Group: "platform", Topic: "go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, p := range projects {
+		got = append(got, p.PathWithNamespace)
+	}
+	want := "platform/api,platform/infra/terraform"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("got %v, want %s", got, want)
+	}
+}
+
+func TestListProjects_MembershipAndArchived(t *testing.T) {
+	fake, client := newFakeGitLab(t, []Project{
+		{ID: 1, PathWithNamespace: "platform/api"},
+		{ID: 2, PathWithNamespace: "platform/legacy", Archived: true},
+	})
+
+	archived := true
+	tests := []struct {
+		opts       ListProjectsOptions
+		want       string
+		membership bool
+	}{
+		{opts: ListProjectsOptions{}, want: "platform/api,platform/legacy", membership: true},
+		{opts: ListProjectsOptions{Archived: &archived}, want: "platform/legacy", membership: true},
+		{opts: ListProjectsOptions{Group: "platform"}, want: "platform/api,platform/legacy"},
+	}
+
+	for _, tt := range tests {
+		fake.membership = nil
+
+		projects, err := client.ListProjects(t.Context(), tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, p := range projects {
+			got = append(got, p.PathWithNamespace)
+		}
+		if strings.Join(got, ",") != tt.want {
+			t.Errorf("%+v: got %v, want %s", tt.opts, got, tt.want)
+		}
+		if len(fake.membership) == 0 || fake.membership[0] != tt.membership {
+			t.Errorf("%+v: expected membership to be %v, got %v", tt.opts, tt.membership, fake.membership)
+		}
+	}
+}
+
+func TestMergeRequestLifecycle(t *testing.T) {
+	fake, client := newFakeGitLab(t, []Project{
+		{ID: 1, PathWithNamespace: "platform/api", DefaultBranch: "main"},
+	})
+
+	mr, err := client.FindMergeRequest(t.Context(), "platform/api", "multipr/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mr != nil {
+		t.Fatalf("expected no MR, got %+v", mr)
+	}
+
+	created, err := client.CreateMergeRequest(t.Context(), "platform/api", CreateMergeRequestOptions{
+		SourceBranch: "multipr/test",
+		TargetBranch: "main",
+		Title:        DraftTitle("chore: test", true),
+		Description:  "body",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created.Draft || created.Title != "Draft: chore: test" {
+		t.Fatalf("expected draft MR, got %+v", created)
+	}
+
+	mr, err = client.FindMergeRequest(t.Context(), "platform/api", "multipr/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mr == nil || mr.IID != created.IID {
+		t.Fatalf("expected to find MR !%d, got %+v", created.IID, mr)
+	}
+
+	updated, err := client.UpdateMergeRequest(t.Context(), "platform/api", mr.IID, UpdateMergeRequestOptions{
+		Title:       DraftTitle(mr.Title, false),
+		Description: "new body",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Draft || updated.Title != "chore: test" || updated.Description != "new body" {
+		t.Fatalf("expected ready MR with new body, got %+v", updated)
+	}
+	if n := len(fake.mrs["platform/api"]); n != 1 {
+		t.Fatalf("expected 1 MR, got %d", n)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	_, client := newFakeGitLab(t, nil)
+	client.token = "wrong"
+
+	_, err := client.GetProject(t.Context(), "platform/api")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 APIError, got %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+// Package gittest serves bare git repositories over git smart HTTP (using `git http-backend`),
+// for the fake hosting services used in tests.
+package gittest
+
+import (
+	"maps"
+	"net/http"
+	"net/http/cgi"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Repos is a directory of bare repositories, which serves them over HTTP at their path below the root,
+// e.g. "/acme/api.git".
+type Repos struct {
+	t       testing.TB
+	root    string
+	handler http.Handler
+}
+
+// New creates an empty directory of repositories. The test is skipped if git is not installed.
+func New(t testing.TB) *Repos {
+	t.Helper()
+
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git is required to serve repositories")
+	}
+
+	root := t.TempDir()
+	return &Repos{
+		t:    t,
+		root: root,
+		handler: &cgi.Handler{
+			Path: gitPath,
+			Args: []string{"http-backend"},
+			Env:  []string{"GIT_PROJECT_ROOT=" + root, "GIT_HTTP_EXPORT_ALL=1"},
+		},
+	}
+}
+
+// IsGitRequest reports whether the request is for a repository rather than an API.
+func IsGitRequest(r *http.Request) bool {
+	return strings.Contains(r.URL.Path, ".git/")
+}
+
+// ServeHTTP serves clones of and pushes to the repositories.
+func (g *Repos) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.handler.ServeHTTP(w, r)
+}
+
+// Dir returns the directory the repositories are stored in.
+func (g *Repos) Dir() string {
+	return g.root
+}
+
+// Create creates a bare repository at the path (e.g. "acme/api.git"), which accepts pushes,
+// with a single commit on the branch containing the files. It returns the directory of the repository.
+func (g *Repos) Create(path, branch string, files map[string]string) string {
+	g.t.Helper()
+
+	bare := g.bare(path)
+	g.git("", "init", "--bare", "--initial-branch="+branch, bare)
+	g.git(bare, "config", "http.receivepack", "true")
+
+	seed := g.t.TempDir()
+	g.git(seed, "init", "--initial-branch="+branch)
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		file := filepath.Join(seed, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			g.t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(files[name]), 0o644); err != nil {
+			g.t.Fatal(err)
+		}
+	}
+	g.git(seed, "add", "-A")
+	g.git(seed, "commit", "--allow-empty", "-m", "initial commit")
+	g.git(seed, "push", bare, branch)
+	return bare
+}
+
+// HasBranch reports whether the branch exists in the repository at the path.
+func (g *Repos) HasBranch(path, branch string) bool {
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
+	cmd.Dir = g.bare(path)
+	return cmd.Run() == nil
+}
+
+// File returns the content of a file on a branch of the repository at the path, e.g. to verify a push.
+func (g *Repos) File(path, branch, file string) string {
+	g.t.Helper()
+	return g.git(g.bare(path), "show", branch+":"+file)
+}
+
+func (g *Repos) bare(path string) string {
+	return filepath.Join(g.root, filepath.FromSlash(path))
+}
+
+func (g *Repos) git(dir string, args ...string) string {
+	g.t.Helper()
+	return Run(g.t, dir, args...)
+}
+
+// Run runs git in dir as a test user, and returns its trimmed output. The test fails if git fails.
+func Run(t testing.TB, dir string, args ...string) string {
+	t.Helper()
+
+	args = append([]string{"-c", "user.name=gittest", "-c", "user.email=gittest@example.com"}, args...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
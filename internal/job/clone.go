@@ -34,6 +34,7 @@ func (m *Manager) cloneRepositories(ctx context.Context, repos []*git.Repo) ([]*
 		m.pool.Submit(func() {
 			m.log.Info(fmt.Sprintf("Cloning repository %s...", repo.String()))
 			if err := repo.Clone(ctx); err != nil {
+				m.summary.record(repo, outcomeFailed, err.Error())
 				mu.Lock()
 				errs = append(errs, fmt.Errorf("failed to clone %s: %w", repo.FullName, err))
 				mu.Unlock()
@@ -42,6 +43,7 @@ func (m *Manager) cloneRepositories(ctx context.Context, repos []*git.Repo) ([]*
 			}
 
 			if err := repo.CheckoutDefaultBranch(ctx); err != nil {
+				m.summary.record(repo, outcomeFailed, err.Error())
 				mu.Lock()
 				errs = append(errs, fmt.Errorf("failed to checkout default branch for %s: %w", repo.FullName, err))
 				mu.Unlock()
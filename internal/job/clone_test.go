@@ -0,0 +1,193 @@
+package job //nolint:testpackage // internal testing needed for unexported functions
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fredrikaverpil/multipr/internal/config"
+	"github.com/fredrikaverpil/multipr/internal/git"
+	"github.com/fredrikaverpil/multipr/internal/gittest"
+	"github.com/fredrikaverpil/multipr/internal/provider/providertest"
+)
+
+func TestRunWorkflow_CloneOptions(t *testing.T) {
+	remotes := gittest.New(t)
+	bare := remotes.Create("acme/monorepo.git", "main", map[string]string{
+		"schedule.txt":         "daily\n",
+		"services/api/main.go": "package main\n",
+	})
+	gittest.Run(t, bare, "config", "uploadpack.allowFilter", "true")
+	// Shallow and partial clones are not supported for local paths
+	memory := providertest.NewMemory("example.com", map[string]string{"acme/monorepo": "file://" + bare})
+
+	cfg := newTestJobConfig()
+	cfg.Search.Repos = []string{"example.com/acme/monorepo"}
+	cfg.Clone = config.Clone{Depth: 1, Filter: "blob:none", Sparse: []string{"/schedule.txt"}}
+
+	m := newWorkflowManagerForTest(t, cfg, &CLIOptions{Publish: true, Shell: "sh", Workers: 2}, memory)
+	// The second run fetches and updates the PR of the existing clone
+	for range 2 {
+		if err := m.RunWorkflow(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	clone := filepath.Join(m.reposDir, "example.com", "acme", "monorepo")
+	if got := gittest.Run(t, clone, "rev-parse", "--is-shallow-repository"); got != "true" {
+		t.Fatalf("expected a shallow clone, got %q", got)
+	}
+	if got := gittest.Run(t, clone, "config", "remote.origin.partialclonefilter"); got != "blob:none" {
+		t.Fatalf("expected a partial clone, got filter %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(clone, "services")); !os.IsNotExist(err) {
+		t.Fatalf("expected paths outside of the sparse-checkout patterns to be missing, got %v", err)
+	}
+
+	if got := gittest.Run(t, bare, "show", testBranch+":schedule.txt"); got != "weekly" {
+		t.Fatalf("expected pushed branch to contain the change, got %q", got)
+	}
+	if got := gittest.Run(t, bare, "show", testBranch+":services/api/main.go"); got != "package main" {
+		t.Fatalf("expected pushed branch to keep paths outside of the sparse checkout, got %q", got)
+	}
+	if n := len(memory.PRs("acme/monorepo")); n != 1 {
+		t.Fatalf("expected a single PR, got %d", n)
+	}
+}
+
+func TestRunWorkflow_Mirrors(t *testing.T) {
+	remotes := gittest.New(t)
+	bare := remotes.Create("acme/daily.git", "main", map[string]string{"schedule.txt": "daily\n"})
+	memory := providertest.NewMemory("example.com", map[string]string{"acme/daily": bare})
+	mirrorsDir := filepath.Join(t.TempDir(), "mirrors")
+
+	// Two jobs share the mirror of the repository, and run at the same time
+	var managers []*Manager
+	for _, branch := range []string{testBranch, "multipr/other"} {
+		cfg := newTestJobConfig()
+		cfg.Search.Repos = []string{"example.com/acme/daily"}
+		cfg.Clone.Mirror = true
+		cfg.PR.GitHub.Branch = branch
+
+		m := newWorkflowManagerForTest(t, cfg, &CLIOptions{Publish: true, Shell: "sh", Workers: 2}, memory)
+		m.mirrors = git.NewMirrors(mirrorsDir, m.exec, m.log)
+		managers = append(managers, m)
+	}
+
+	errs := make(chan error, len(managers))
+	for _, m := range managers {
+		go func() { errs <- m.RunWorkflow(t.Context()) }()
+	}
+	for range managers {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mirror := filepath.Join(mirrorsDir, "example.com", "acme", "daily.git")
+	if got := gittest.Run(t, mirror, "rev-parse", "--is-bare-repository"); got != "true" {
+		t.Fatalf("expected a bare mirror, got %q", got)
+	}
+	if _, err := os.Stat(mirror + ".lock"); !os.IsNotExist(err) {
+		t.Fatalf("expected the mirror to be unlocked, got %v", err)
+	}
+	for _, m := range managers {
+		clone := filepath.Join(m.reposDir, "example.com", "acme", "daily")
+		alternates, err := os.ReadFile(filepath.Join(clone, ".git", "objects", "info", "alternates"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.TrimSpace(string(alternates)); got != filepath.Join(mirror, "objects") {
+			t.Fatalf("expected the clone to borrow objects from the mirror, got %q", got)
+		}
+		// The clone still pushes to the repository, not the mirror
+		if got := gittest.Run(t, clone, "remote", "get-url", "origin"); got != bare {
+			t.Fatalf("expected origin to be the repository, got %q", got)
+		}
+	}
+
+	if n := len(memory.PRs("acme/daily")); n != 2 {
+		t.Fatalf("expected a PR from each job, got %d", n)
+	}
+}
+
+func TestRunWorkflow_ShallowMirror(t *testing.T) {
+	remotes := gittest.New(t)
+	bare := remotes.Create("acme/daily.git", "main", map[string]string{"schedule.txt": "daily\n"})
+	gittest.Run(t, bare, "config", "uploadpack.allowFilter", "true")
+	// Shallow and partial clones are not supported for local paths
+	url := "file://" + bare
+	memory := providertest.NewMemory("example.com", map[string]string{"acme/daily": url})
+	mirrorsDir := filepath.Join(t.TempDir(), "mirrors")
+
+	cfg := newTestJobConfig()
+	cfg.Search.Repos = []string{"example.com/acme/daily"}
+	cfg.Clone = config.Clone{Depth: 1, Filter: "blob:none", Mirror: true}
+
+	m := newWorkflowManagerForTest(t, cfg, &CLIOptions{Publish: true, Shell: "sh", Workers: 2}, memory)
+	m.mirrors = git.NewMirrors(mirrorsDir, m.exec, m.log)
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	mirror := filepath.Join(mirrorsDir, "example.com", "acme", "daily.blob-none.depth-1.git")
+	if got := gittest.Run(t, mirror, "rev-parse", "--is-shallow-repository"); got != "true" {
+		t.Fatalf("expected a shallow mirror, got %q", got)
+	}
+	if got := gittest.Run(t, mirror, "config", "remote.origin.partialclonefilter"); got != "blob:none" {
+		t.Fatalf("expected a partial mirror, got filter %q", got)
+	}
+
+	clone := filepath.Join(m.reposDir, "example.com", "acme", "daily")
+	if got := gittest.Run(t, clone, "rev-parse", "--is-shallow-repository"); got != "true" {
+		t.Fatalf("expected a shallow clone, got %q", got)
+	}
+	if got := gittest.Run(t, clone, "remote", "get-url", "origin"); got != url {
+		t.Fatalf("expected origin to be the repository, got %q", got)
+	}
+	if got := gittest.Run(t, bare, "show", testBranch+":schedule.txt"); got != "weekly" {
+		t.Fatalf("expected pushed branch to contain the change, got %q", got)
+	}
+}
+
+func TestRunWorkflow_FetchTTL(t *testing.T) {
+	remotes := gittest.New(t)
+	bare := remotes.Create("acme/daily.git", "main", map[string]string{"schedule.txt": "daily\n"})
+	memory := providertest.NewMemory("example.com", map[string]string{"acme/daily": bare})
+
+	cfg := newTestJobConfig()
+	cfg.Search.Repos = []string{"example.com/acme/daily"}
+	cfg.Clone.FetchTTL = time.Hour
+
+	m := newWorkflowManagerForTest(t, cfg, &CLIOptions{Shell: "sh", Workers: 2}, memory)
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	clone := filepath.Join(m.reposDir, "example.com", "acme", "daily")
+	initial := gittest.Run(t, clone, "rev-parse", "origin/main")
+
+	// A new commit on the default branch
+	upstream := t.TempDir()
+	gittest.Run(t, upstream, "clone", bare, ".")
+	gittest.Run(t, upstream, "commit", "--allow-empty", "-m", "upstream change")
+	gittest.Run(t, upstream, "push", "origin", "main")
+	latest := gittest.Run(t, upstream, "rev-parse", "HEAD")
+
+	// The clone was fetched within the TTL
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if got := gittest.Run(t, clone, "rev-parse", "origin/main"); got != initial {
+		t.Fatalf("expected the clone not to be fetched within the TTL, got %s", got)
+	}
+
+	m.config.Clone.FetchTTL = 0
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if got := gittest.Run(t, clone, "rev-parse", "origin/main"); got != latest {
+		t.Fatalf("expected the clone to be fetched without a TTL, got %s", got)
+	}
+}
@@ -0,0 +1,112 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/fredrikaverpil/multipr/internal/command"
+	"github.com/fredrikaverpil/multipr/internal/git"
+	"github.com/fredrikaverpil/multipr/internal/log"
+)
+
+const (
+	patchesDir = "patches"
+
+	// Placeholders of the cover letter written by git format-patch
+	coverSubjectPlaceholder = "*** SUBJECT HERE ***"
+	coverBlurbPlaceholder   = "*** BLURB HERE ***"
+)
+
+// mboxSeparator is the "From <commit> <date>" line which starts each message written by git format-patch,
+// where the date is fixed so that it can be recognized.
+var mboxSeparator = regexp.MustCompile(`(?m)^From [0-9a-f]{40,64} Mon Sep 17 00:00:00 2001\n`)
+
+// mboxPath returns the path of the patch series of the repository,
+// e.g. <workDir>/patches/github.com/owner/repo.mbox.
+func (m *Manager) mboxPath(repo *git.Repo) string {
+	return filepath.Join(m.workDir, patchesDir, repo.Host, filepath.FromSlash(repo.FullName)+".mbox")
+}
+
+// mailPatches formats the commits of the repository as a patch series in an mbox, with the PR title and body
+// as cover letter, and sends it with the sendmail command if one is configured.
+// Drafts are marked as RFC in the subject prefix.
+func (m *Manager) mailPatches(ctx context.Context, repo *git.Repo) error {
+	email := m.config.Publish.Email
+
+	base, err := repo.DefaultBranch(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get default branch of %s: %w", repo.LocalPath(), err)
+	}
+
+	prefix := email.SubjectPrefix
+	if prefix == "" {
+		prefix = "PATCH"
+	}
+	if m.options.Draft {
+		prefix = "RFC " + prefix
+	}
+	args := []string{"--cover-letter", "--thread=shallow", "--subject-prefix=" + prefix}
+	for _, to := range email.To {
+		args = append(args, "--to="+to)
+	}
+	for _, cc := range email.Cc {
+		args = append(args, "--cc="+cc)
+	}
+
+	mbox, err := repo.FormatPatch(ctx, base, args...)
+	if err != nil {
+		return fmt.Errorf("failed to format patches for %s: %w", repo.LocalPath(), err)
+	}
+	pr := m.prConfig(repo)
+	mbox = fillCoverLetter(mbox, pr.Title, m.processBodyTemplate(pr.Body))
+	messages := splitMbox(mbox)
+	if len(messages) < 2 {
+		return fmt.Errorf("no commits to format as patches in %s", repo.LocalPath())
+	}
+
+	// The series replaces the series of previous runs, which may have had more patches
+	path := m.mboxPath(repo)
+	if err = os.MkdirAll(filepath.Dir(path), DefaultFilePerms); err != nil {
+		return fmt.Errorf("failed to create patches directory: %w", err)
+	}
+	if err = os.WriteFile(path, []byte(mbox+"\n"), log.RegularFilePerms); err != nil {
+		return fmt.Errorf("failed to write patches of %s: %w", repo.LocalPath(), err)
+	}
+	m.log.Info(fmt.Sprintf("Wrote %d patches of %s to %s", len(messages)-1, repo.String(), path))
+
+	if email.Sendmail == "" {
+		m.summary.record(repo, outcomePatched, path)
+		return nil
+	}
+	for _, message := range messages {
+		stdin := command.WithStdin(strings.NewReader(message))
+		if _, err = m.exec.ExecuteWithShell(ctx, email.Sendmail, "", stdin); err != nil {
+			return fmt.Errorf("failed to send patches of %s: %w", repo.LocalPath(), err)
+		}
+	}
+	m.log.Info(fmt.Sprintf("Sent %d patches of %s", len(messages)-1, repo.String()))
+	m.summary.record(repo, outcomeMailed, strings.Join(email.To, ", "))
+	return nil
+}
+
+// fillCoverLetter replaces the subject and blurb placeholders of the cover letter, the first message of the series.
+func fillCoverLetter(message, subject, blurb string) string {
+	message = strings.Replace(message, coverSubjectPlaceholder, subject, 1)
+	return strings.Replace(message, coverBlurbPlaceholder, strings.TrimSpace(blurb), 1)
+}
+
+// splitMbox returns the messages of the mbox written by git format-patch, without their separator lines,
+// which are not part of the messages.
+func splitMbox(mbox string) []string {
+	var messages []string
+	for _, message := range mboxSeparator.Split(mbox, -1) {
+		if strings.TrimSpace(message) != "" {
+			messages = append(messages, strings.TrimSuffix(message, "\n")+"\n")
+		}
+	}
+	return messages
+}
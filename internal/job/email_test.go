@@ -0,0 +1,77 @@
+package job //nolint:testpackage // internal testing needed for unexported functions
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fredrikaverpil/multipr/internal/config"
+	"github.com/fredrikaverpil/multipr/internal/gittest"
+	"github.com/fredrikaverpil/multipr/internal/provider/providertest"
+)
+
+func TestRunWorkflow_Email(t *testing.T) {
+	remotes := gittest.New(t)
+	memory := providertest.NewMemory("example.com", map[string]string{
+		"acme/daily": remotes.Create("acme/daily.git", "main", map[string]string{"schedule.txt": "daily\n"}),
+	})
+
+	outbox := filepath.Join(t.TempDir(), "outbox")
+	cfg := newTestJobConfig()
+	cfg.Search.Repos = []string{"example.com/acme/daily"}
+	cfg.Publish = config.Publish{
+		Mode: config.PublishModeEmail,
+		Email: config.Email{
+			To:       []string{"dev@lists.example.com"},
+			Sendmail: "cat >> " + outbox,
+		},
+	}
+
+	m := newWorkflowManagerForTest(t, cfg, &CLIOptions{Publish: true, Draft: true, Shell: "sh", Workers: 2}, memory)
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The series is written to an mbox in the job work dir
+	mbox := filepath.Join(m.workDir, "patches", "example.com", "acme", "daily.mbox")
+	written, err := os.ReadFile(mbox)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(written), "\nSubject: [RFC PATCH "); n != 2 {
+		t.Fatalf("expected a cover letter and a patch, got %d messages:\n%s", n, written)
+	}
+	if !strings.Contains(string(written), "Subject: [RFC PATCH 0/1] chore: weekly schedule\n") {
+		t.Fatalf("expected the cover letter to hold the PR title, got:\n%s", written)
+	}
+
+	// The cover letter holds the PR title and body, and is sent before the patch
+	data, err := os.ReadFile(outbox)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := string(data)
+	for _, want := range []string{
+		"To: dev@lists.example.com",
+		"Subject: [RFC PATCH 0/1] chore: weekly schedule\n",
+		"\nbody\n",
+		"Subject: [RFC PATCH 1/1] chore: weekly schedule\n",
+		"+weekly",
+	} {
+		if !strings.Contains(sent, want) {
+			t.Fatalf("expected sent messages to contain %q, got:\n%s", want, sent)
+		}
+	}
+	if strings.HasPrefix(sent, "From ") || strings.Index(sent, "0/1") > strings.Index(sent, "1/1") {
+		t.Fatalf("unexpected sent messages:\n%s", sent)
+	}
+
+	// Nothing is pushed and no PR is opened
+	if remotes.HasBranch("acme/daily.git", testBranch) {
+		t.Fatal("expected no pushed branch")
+	}
+	if n := len(memory.PRs("acme/daily")); n != 0 {
+		t.Fatalf("expected no PR in email mode, got %d", n)
+	}
+}
@@ -0,0 +1,247 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fredrikaverpil/multipr/internal/config"
+	"github.com/fredrikaverpil/multipr/internal/provider"
+)
+
+const (
+	hoursPerDay = 24
+	daysPerWeek = 7
+)
+
+// repoFilter is the compiled form of the search filters.
+type repoFilter struct {
+	cfg          config.Filters
+	include      []nameMatcher
+	exclude      []nameMatcher
+	pushedWithin time.Duration
+	now          time.Time
+}
+
+// nameMatcher matches a repository name against a glob or regular expression.
+type nameMatcher struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+func newRepoFilter(cfg config.Filters, now time.Time) (*repoFilter, error) {
+	f := &repoFilter{cfg: cfg, now: now}
+
+	var err error
+	if f.include, err = compileNameMatchers(cfg.Include); err != nil {
+		return nil, err
+	}
+	if f.exclude, err = compileNameMatchers(cfg.Exclude); err != nil {
+		return nil, err
+	}
+	if cfg.PushedWithin != "" {
+		if f.pushedWithin, err = parseAge(cfg.PushedWithin); err != nil {
+			return nil, fmt.Errorf("invalid pushed_within: %w", err)
+		}
+	}
+
+	return f, nil
+}
+
+func compileNameMatchers(patterns []string) ([]nameMatcher, error) {
+	matchers := make([]nameMatcher, 0, len(patterns))
+	for _, pattern := range patterns {
+		if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+			re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter pattern %s: %w", pattern, err)
+			}
+			matchers = append(matchers, nameMatcher{pattern: pattern, re: re})
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid filter pattern %s: %w", pattern, err)
+		}
+		matchers = append(matchers, nameMatcher{pattern: pattern})
+	}
+	return matchers, nil
+}
+
+// matches reports whether the full name (e.g. "owner/repo") or the name including the host
+// (e.g. "github.com/owner/repo") matches.
+func (n nameMatcher) matches(repo provider.Repository) bool {
+	for _, name := range []string{repo.FullName, repo.String()} {
+		if n.re != nil {
+			if n.re.MatchString(name) {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(n.pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// needsMetadata returns true if any filter depends on repository metadata.
+func (f *repoFilter) needsMetadata() bool {
+	c := f.cfg
+	return c.Archived != nil || c.Fork != nil || c.Template != nil || len(c.Visibility) > 0 ||
+		len(c.Languages) > 0 || len(c.Topics) > 0 || len(c.ExcludeTopics) > 0 || f.pushedWithin > 0
+}
+
+// reason returns why the repository is filtered out, or an empty string if it is kept.
+func (f *repoFilter) reason(repo provider.Repository) string {
+	if len(f.include) > 0 && !slices.ContainsFunc(f.include, func(n nameMatcher) bool { return n.matches(repo) }) {
+		return "name not included"
+	}
+	for _, n := range f.exclude {
+		if n.matches(repo) {
+			return fmt.Sprintf("name excluded by %s", n.pattern)
+		}
+	}
+
+	c := f.cfg
+	if c.Archived != nil && repo.Archived != *c.Archived {
+		return boolReason("archived", repo.Archived)
+	}
+	if c.Fork != nil && repo.Fork != *c.Fork {
+		return boolReason("fork", repo.Fork)
+	}
+	if c.Template != nil && repo.Template != *c.Template {
+		return boolReason("template", repo.Template)
+	}
+	if len(c.Visibility) > 0 && !containsFold(c.Visibility, repo.Visibility) {
+		return fmt.Sprintf("visibility is %q", repo.Visibility)
+	}
+	if len(c.Languages) > 0 && !containsFold(c.Languages, repo.Language) {
+		return fmt.Sprintf("language is %q", repo.Language)
+	}
+	hasTopic := func(topic string) bool { return containsFold(c.Topics, topic) }
+	if len(c.Topics) > 0 && !slices.ContainsFunc(repo.Topics, hasTopic) {
+		return "none of the topics"
+	}
+	for _, topic := range repo.Topics {
+		if containsFold(c.ExcludeTopics, topic) {
+			return fmt.Sprintf("topic %q excluded", topic)
+		}
+	}
+	if f.pushedWithin > 0 && repo.PushedAt.Before(f.now.Add(-f.pushedWithin)) {
+		if repo.PushedAt.IsZero() {
+			return "never pushed"
+		}
+		return fmt.Sprintf("last pushed %s", repo.PushedAt.Format(time.DateOnly))
+	}
+
+	return ""
+}
+
+func boolReason(name string, value bool) string {
+	if value {
+		return "is " + name
+	}
+	return "is not " + name
+}
+
+func containsFold(values []string, value string) bool {
+	return slices.ContainsFunc(values, func(v string) bool { return strings.EqualFold(v, value) })
+}
+
+// parseAge parses a duration, which in addition to time.ParseDuration units
+// accepts whole days ("90d") and weeks ("2w").
+func parseAge(s string) (time.Duration, error) {
+	for suffix, hours := range map[string]int{"d": hoursPerDay, "w": hoursPerDay * daysPerWeek} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			value, err := strconv.Atoi(n)
+			if err != nil {
+				return 0, fmt.Errorf("invalid age %q", s)
+			}
+			return time.Duration(value*hours) * time.Hour, nil
+		}
+	}
+	return time.ParseDuration(s)
+}
+
+// filterRepositories drops the repositories which do not pass the search filters, and logs why.
+// Metadata is fetched from the provider for repositories which the search did not return it for.
+func (m *Manager) filterRepositories(
+	ctx context.Context,
+	repos []provider.Repository,
+) ([]provider.Repository, error) {
+	filter, err := newRepoFilter(m.config.Search.Filters, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.needsMetadata() {
+		if repos, err = m.fetchMetadata(ctx, repos); err != nil {
+			return nil, err
+		}
+	}
+
+	var kept []provider.Repository
+	var dropped []string
+	for _, repo := range repos {
+		if reason := filter.reason(repo); reason != "" {
+			dropped = append(dropped, fmt.Sprintf("  - %s (%s)", repo.String(), reason))
+			continue
+		}
+		kept = append(kept, repo)
+	}
+
+	if len(dropped) > 0 {
+		m.log.Info(fmt.Sprintf("Filtered out %d repositories", len(dropped)))
+		for _, line := range dropped {
+			m.log.Info(line)
+		}
+	}
+
+	return kept, nil
+}
+
+// fetchMetadata fills in the metadata of repositories which lack it.
+func (m *Manager) fetchMetadata(ctx context.Context, repos []provider.Repository) ([]provider.Repository, error) {
+	var mu sync.Mutex
+	var errs []error
+	result := slices.Clone(repos)
+
+	for _, repo := range result {
+		if _, ok := m.providers.Get(repo.Host); !ok {
+			return nil, fmt.Errorf("no provider configured for host %s", repo.Host)
+		}
+	}
+
+	for i, repo := range result {
+		if repo.HasMetadata {
+			continue
+		}
+
+		p, _ := m.providers.Get(repo.Host)
+		m.pool.Submit(func() {
+			full, fetchErr := p.Repository(ctx, repo.FullName)
+			mu.Lock()
+			defer mu.Unlock()
+			if fetchErr != nil {
+				errs = append(errs, fmt.Errorf("failed to get metadata for %s: %w", repo.String(), fetchErr))
+				return
+			}
+			result[i] = *full
+		})
+	}
+
+	m.pool.Wait()
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return result, nil
+}
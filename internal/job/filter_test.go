@@ -0,0 +1,104 @@
+package job //nolint:testpackage // internal testing needed for unexported functions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fredrikaverpil/multipr/internal/config"
+	"github.com/fredrikaverpil/multipr/internal/provider"
+)
+
+func TestRepoFilter_Reason(t *testing.T) {
+	now := time.Date(2026, time.June, 1, 0, 0, 0, 0, time.UTC)
+	no := false
+	filter, err := newRepoFilter(config.Filters{
+		Include:       []string{"acme/*", "gitlab.example.com/platform/*/*"},
+		Exclude:       []string{"/-deprecated$/"},
+		Archived:      &no,
+		Fork:          &no,
+		Visibility:    []string{"public", "internal"},
+		Languages:     []string{"go"},
+		ExcludeTopics: []string{"frozen"},
+		PushedWithin:  "90d",
+	}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := provider.Repository{
+		Host:        "github.com",
+		FullName:    "acme/api",
+		HasMetadata: true,
+		Visibility:  "public",
+		Language:    "Go",
+		PushedAt:    now.AddDate(0, 0, -10),
+	}
+
+	tests := []struct {
+		name   string
+		modify func(r *provider.Repository)
+		want   string
+	}{
+		{name: "kept", modify: func(*provider.Repository) {}, want: ""},
+		{
+			name: "included by host glob",
+			modify: func(r *provider.Repository) {
+				r.Host = "gitlab.example.com"
+				r.FullName = "platform/infra/terraform"
+			},
+			want: "",
+		},
+		{name: "not included", modify: func(r *provider.Repository) { r.FullName = "other/api" }, want: "name not included"},
+		{
+			name:   "excluded by regex",
+			modify: func(r *provider.Repository) { r.FullName = "acme/api-deprecated" },
+			want:   "name excluded by /-deprecated$/",
+		},
+		{name: "archived", modify: func(r *provider.Repository) { r.Archived = true }, want: "is archived"},
+		{name: "fork", modify: func(r *provider.Repository) { r.Fork = true }, want: "is fork"},
+		{
+			name:   "private",
+			modify: func(r *provider.Repository) { r.Visibility = "private" },
+			want:   `visibility is "private"`,
+		},
+		{name: "language", modify: func(r *provider.Repository) { r.Language = "Rust" }, want: `language is "Rust"`},
+		{
+			name:   "excluded topic",
+			modify: func(r *provider.Repository) { r.Topics = []string{"Frozen"} },
+			want:   `topic "Frozen" excluded`,
+		},
+		{
+			name:   "stale",
+			modify: func(r *provider.Repository) { r.PushedAt = now.AddDate(-1, 0, 0) },
+			want:   "last pushed 2025-06-01",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := base
+			tt.modify(&repo)
+			if got := filter.reason(repo); got != tt.want {
+				t.Fatalf("got reason %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := map[string]time.Duration{
+		"90d":  90 * 24 * time.Hour,
+		"2w":   14 * 24 * time.Hour,
+		"720h": 720 * time.Hour,
+	}
+	for input, want := range tests {
+		got, err := parseAge(input)
+		if err != nil || got != want {
+			t.Errorf("parseAge(%q) = %v, %v; want %v", input, got, err, want)
+		}
+	}
+
+	if _, err := parseAge("soon"); err == nil {
+		t.Error("expected error for invalid age")
+	}
+}
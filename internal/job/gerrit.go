@@ -0,0 +1,59 @@
+package job
+
+import (
+	"context"
+	"crypto/sha1" //nolint:gosec // Change-Ids have the shape of a SHA-1, they are not a security measure
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/fredrikaverpil/multipr/internal/git"
+)
+
+const changeIDTrailer = "Change-Id"
+
+// changeID derives the Gerrit Change-Id of the job's change in the repository, e.g. "github.com/owner/repo".
+// It is stable across runs, so that re-runs upload new patchsets to the same change.
+func changeID(jobName, repo string) string {
+	sum := sha1.Sum([]byte(jobName + "\n" + repo)) //nolint:gosec // see import
+	return "I" + hex.EncodeToString(sum[:])
+}
+
+// uploadChange uploads the commit of the repository as a Gerrit change for review, by pushing it to
+// refs/for/<default branch>. The change is tagged with the branch name as topic, and marked as work
+// in progress when publishing drafts.
+func (m *Manager) uploadChange(ctx context.Context, repo *git.Repo) error {
+	base, err := repo.DefaultBranch(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get default branch of %s: %w", repo.LocalPath(), err)
+	}
+
+	// Every commit becomes a change of its own, so only the single commit of the job can be tracked
+	ahead, err := repo.CommitsAhead(ctx, base)
+	if err != nil {
+		return fmt.Errorf("failed to inspect commits of %s: %w", repo.LocalPath(), err)
+	}
+	if ahead != 1 {
+		return fmt.Errorf("gerrit publish mode requires a single commit in %s, found %d", repo.LocalPath(), ahead)
+	}
+
+	id := changeID(m.config.Name, repo.String())
+	if err = repo.SetTrailer(ctx, changeIDTrailer, id); err != nil {
+		return fmt.Errorf("failed to set Change-Id for %s: %w", repo.LocalPath(), err)
+	}
+
+	options := []string{"topic=" + m.prConfig(repo).Branch}
+	if m.options.Draft {
+		options = append(options, "wip")
+	} else {
+		options = append(options, "ready")
+	}
+	ref := fmt.Sprintf("refs/for/%s%%%s", base, strings.Join(options, ","))
+	if err = repo.PushRef(ctx, ref); err != nil {
+		return fmt.Errorf("failed to upload change for %s: %w", repo.LocalPath(), err)
+	}
+
+	m.log.Info(fmt.Sprintf("Uploaded change %s of %s for review on %s", id, repo.String(), base))
+	m.summary.record(repo, outcomeUploaded, id)
+	return nil
+}
@@ -0,0 +1,78 @@
+package job //nolint:testpackage // internal testing needed for unexported functions
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fredrikaverpil/multipr/internal/config"
+	"github.com/fredrikaverpil/multipr/internal/gittest"
+	"github.com/fredrikaverpil/multipr/internal/provider/providertest"
+)
+
+// gerritHooks emulate Gerrit in a bare repository: commits pushed to refs/for/<branch> must have
+// a Change-Id trailer, and are stored as patchsets in refs/changes/<Change-Id>/<n> instead.
+var gerritHooks = map[string]string{
+	"pre-receive": `#!/bin/sh
+while read old new ref; do
+	case "$ref" in refs/for/*) ;; *) echo "only refs/for/* can be pushed" >&2; exit 1 ;; esac
+	git log -1 --format=%B "$new" | grep -q '^Change-Id: I' || { echo "missing Change-Id" >&2; exit 1; }
+done
+`,
+	"post-receive": `#!/bin/sh
+while read old new ref; do
+	id=$(git log -1 --format=%B "$new" | sed -n 's/^Change-Id: //p')
+	n=$(git for-each-ref "refs/changes/$id/" | wc -l)
+	git update-ref "refs/changes/$id/$((n + 1))" "$new"
+	git update-ref -d "$ref"
+	echo "${ref#*%}" >> options.txt
+done
+`,
+}
+
+func TestRunWorkflow_Gerrit(t *testing.T) {
+	remotes := gittest.New(t)
+	bare := remotes.Create("acme/daily.git", "main", map[string]string{"schedule.txt": "daily\n"})
+	for name, script := range gerritHooks {
+		if err := os.WriteFile(filepath.Join(bare, "hooks", name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	memory := providertest.NewMemory("example.com", map[string]string{"acme/daily": bare})
+
+	cfg := newTestJobConfig()
+	cfg.Search.Repos = []string{"example.com/acme/daily"}
+	cfg.Publish.Mode = config.PublishModeGerrit
+
+	opts := &CLIOptions{Publish: true, Draft: true, Shell: "sh", Workers: 2}
+	m := newWorkflowManagerForTest(t, cfg, opts, memory)
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Re-running uploads a new patchset of the same change
+	opts.Draft = false
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	id := changeID("test", "example.com/acme/daily")
+	refs := gittest.Run(t, bare, "for-each-ref", "--format=%(refname)", "refs/changes/")
+	if want := "refs/changes/" + id + "/1\nrefs/changes/" + id + "/2"; refs != want {
+		t.Fatalf("expected two patchsets of %s, got:\n%s", id, refs)
+	}
+	if got := gittest.Run(t, bare, "show", "refs/changes/"+id+"/2:schedule.txt"); got != "weekly" {
+		t.Fatalf("expected the patchset to contain the change, got %q", got)
+	}
+
+	options, err := os.ReadFile(filepath.Join(bare, "options.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "topic=" + testBranch + ",wip\ntopic=" + testBranch + ",ready\n"; string(options) != want {
+		t.Fatalf("unexpected push options:\n%s", options)
+	}
+	if n := len(memory.PRs("acme/daily")); n != 0 {
+		t.Fatalf("expected no PR in gerrit mode, got %d", n)
+	}
+}
@@ -6,24 +6,60 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 
 	"github.com/fredrikaverpil/multipr/internal/command"
 	"github.com/fredrikaverpil/multipr/internal/git"
 	"github.com/fredrikaverpil/multipr/internal/log"
+	"github.com/fredrikaverpil/multipr/internal/provider"
 )
 
-func (m *Manager) identifyEligibleRepos(ctx context.Context, reposDir string) ([]*git.Repo, error) {
+func (m *Manager) identifyEligibleRepos(
+	ctx context.Context,
+	reposDir string,
+	searched []*git.Repo,
+) ([]*git.Repo, error) {
 	m.log.Info("Identifying eligible repositories...")
 
 	var mu sync.Mutex
 	var eligibleRepos []*git.Repo
 	var errs []error
 
-	repos, err := rebuildRepos(reposDir, m.exec, m.log)
+	repos, err := rebuildRepos(reposDir, m.providers, m.exec, m.log)
 	if err != nil {
 		return nil, fmt.Errorf("failed to rebuild repositories: %w", err)
 	}
+	for _, repo := range repos {
+		m.configureRepo(repo)
+	}
+
+	if searched != nil {
+		var stale []*git.Repo
+		repos, stale = reconcileRepos(repos, searched)
+		// Clones of skipped repositories (e.g. archived ones) are kept, but not used
+		stale = slices.DeleteFunc(stale, m.summary.isSkipped)
+		if err = m.handleStaleRepos(stale); err != nil {
+			return nil, err
+		}
+	}
+
+	// The clones are repaired here rather than when cloning, as they are not cloned without a search,
+	// or when cloning is declined with -review
+	for _, repo := range repos {
+		m.pool.Submit(func() {
+			if repairErr := m.repairRepository(ctx, repo); repairErr != nil {
+				m.summary.record(repo, outcomeFailed, repairErr.Error())
+				mu.Lock()
+				errs = append(errs, repairErr)
+				mu.Unlock()
+			}
+		})
+	}
+	m.pool.Wait()
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
 
 	// If there are no identification commands, consider all repos eligible
 	if len(m.config.Identify) == 0 {
@@ -35,17 +71,20 @@ func (m *Manager) identifyEligibleRepos(ctx context.Context, reposDir string) ([
 		m.pool.Submit(func() {
 			eligible, checkoutErr := m.isRepoEligible(ctx, repo)
 			if checkoutErr != nil {
+				m.summary.record(repo, outcomeFailed, checkoutErr.Error())
 				mu.Lock()
 				errs = append(errs, checkoutErr)
 				mu.Unlock()
 				return
 			}
 
-			if eligible {
-				mu.Lock()
-				eligibleRepos = append(eligibleRepos, repo)
-				mu.Unlock()
+			if !eligible {
+				m.summary.record(repo, outcomeNotEligible, "")
+				return
 			}
+			mu.Lock()
+			eligibleRepos = append(eligibleRepos, repo)
+			mu.Unlock()
 		})
 	}
 
@@ -59,6 +98,20 @@ func (m *Manager) identifyEligibleRepos(ctx context.Context, reposDir string) ([
 	return eligibleRepos, nil
 }
 
+// repairRepository fixes an existing clone which an interrupted run left in a broken state, e.g. with a rebase
+// in progress, and logs each fix.
+func (m *Manager) repairRepository(ctx context.Context, repo *git.Repo) error {
+	m.log.Debug(fmt.Sprintf("Repairing %s", repo.String()))
+	fixes, err := repo.Repair(ctx)
+	for _, fix := range fixes {
+		m.log.Info(fmt.Sprintf("Repaired %s: %s", repo.String(), fix))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to repair %s: %w", repo.FullName, err)
+	}
+	return nil
+}
+
 // isRepoEligible checks if a repository is eligible based on identification commands.
 func (m *Manager) isRepoEligible(ctx context.Context, repo *git.Repo) (bool, error) {
 	checkoutErr := repo.CheckoutDefaultBranch(ctx)
@@ -104,7 +157,12 @@ func (m *Manager) logEligibleRepos(eligibleRepos []*git.Repo) {
 }
 
 // FIXME: move into git/repo.go?
-func rebuildRepos(reposDir string, executor *command.Executor, log *log.Logger) ([]*git.Repo, error) {
+func rebuildRepos(
+	reposDir string,
+	providers *provider.Registry,
+	executor *command.Executor,
+	log *log.Logger,
+) ([]*git.Repo, error) {
 	var repos []*git.Repo
 
 	hosts, readErr := os.ReadDir(reposDir)
@@ -117,7 +175,13 @@ func rebuildRepos(reposDir string, executor *command.Executor, log *log.Logger)
 			continue
 		}
 
-		hostRepos, hostErr := processHostDir(reposDir, host.Name(), executor, log)
+		p, ok := providers.Get(host.Name())
+		if !ok {
+			log.Warn(fmt.Sprintf("No provider configured for host %s, skipping its repositories", host.Name()))
+			continue
+		}
+
+		hostRepos, hostErr := processHostDir(reposDir, p, executor, log)
 		if hostErr != nil {
 			return nil, hostErr
 		}
@@ -129,52 +193,62 @@ func rebuildRepos(reposDir string, executor *command.Executor, log *log.Logger)
 }
 
 // processHostDir processes a host directory and returns repositories.
-func processHostDir(reposDir, hostName string, executor *command.Executor, log *log.Logger) ([]*git.Repo, error) {
+func processHostDir(
+	reposDir string,
+	p provider.Provider,
+	executor *command.Executor,
+	log *log.Logger,
+) ([]*git.Repo, error) {
 	var repos []*git.Repo
-	var fullNames []string
-
-	hostPath := filepath.Join(reposDir, hostName)
-	usernames, readErr := os.ReadDir(hostPath)
-	if readErr != nil {
-		return nil, fmt.Errorf("failed to read directory %s: %w", hostPath, readErr)
-	}
-
-	for _, username := range usernames {
-		if !username.IsDir() {
-			continue
-		}
 
-		userFullNames, userErr := getUserProjects(reposDir, hostName, username.Name())
-		if userErr != nil {
-			return nil, userErr
-		}
-
-		fullNames = append(fullNames, userFullNames...)
+	hostPath := filepath.Join(reposDir, p.Host())
+	fullNames, err := findProjects(hostPath, "")
+	if err != nil {
+		return nil, err
 	}
 
 	for _, fullName := range fullNames {
-		repo := git.NewRepo(hostName, fullName, reposDir, executor, log)
+		repo := git.NewRepo(p, fullName, reposDir, executor, log)
 		repos = append(repos, repo)
 	}
 
 	return repos, nil
 }
 
-// getUserProjects gets a list of project full names for a user.
-func getUserProjects(reposDir, hostName, userName string) ([]string, error) {
+// findProjects walks a host directory and returns the full names of all git repositories in it.
+// Namespaces can be nested (e.g. GitLab subgroups), so the walk stops at the first directory
+// which contains a .git folder.
+func findProjects(hostPath, namespace string) ([]string, error) {
 	var fullNames []string
 
-	userPath := filepath.Join(reposDir, hostName, userName)
-	projects, readErr := os.ReadDir(userPath)
+	dirPath := filepath.Join(hostPath, filepath.FromSlash(namespace))
+	entries, readErr := os.ReadDir(dirPath)
 	if readErr != nil {
-		return nil, fmt.Errorf("failed to read directory %s: %w", userPath, readErr)
+		return nil, fmt.Errorf("failed to read directory %s: %w", dirPath, readErr)
 	}
 
-	for _, project := range projects {
-		if !project.IsDir() {
+	for _, entry := range entries {
+		if !entry.IsDir() {
 			continue
 		}
-		fullNames = append(fullNames, fmt.Sprintf("%s/%s", userName, project.Name()))
+
+		fullName := entry.Name()
+		if namespace != "" {
+			fullName = namespace + "/" + entry.Name()
+		}
+
+		if _, err := os.Stat(filepath.Join(dirPath, entry.Name(), ".git")); err == nil {
+			if namespace != "" {
+				fullNames = append(fullNames, fullName)
+			}
+			continue
+		}
+
+		nested, err := findProjects(hostPath, fullName)
+		if err != nil {
+			return nil, err
+		}
+		fullNames = append(fullNames, nested...)
 	}
 
 	return fullNames, nil
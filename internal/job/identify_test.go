@@ -0,0 +1,110 @@
+package job //nolint:testpackage // internal testing needed for unexported functions
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fredrikaverpil/multipr/internal/gittest"
+	"github.com/fredrikaverpil/multipr/internal/log"
+	"github.com/fredrikaverpil/multipr/internal/provider"
+	"github.com/fredrikaverpil/multipr/internal/provider/providertest"
+)
+
+func TestRunWorkflow_RepairsClone(t *testing.T) {
+	remotes := gittest.New(t)
+	bare := remotes.Create("acme/daily.git", "main", map[string]string{"schedule.txt": "daily\n"})
+	memory := providertest.NewMemory("example.com", map[string]string{"acme/daily": bare})
+	memory.SetMetadata(provider.Repository{FullName: "acme/daily", DefaultBranch: "main"})
+
+	cfg := newTestJobConfig()
+	cfg.Search.Repos = []string{"example.com/acme/daily"}
+
+	m := newWorkflowManagerForTest(t, cfg, &CLIOptions{Shell: "sh", Workers: 2}, memory)
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Leave the clone as an interrupted run would: with a conflicting rebase in progress (and so a detached HEAD),
+	// a stale index lock and no origin/HEAD, which cannot be restored from the remote either
+	clone := filepath.Join(m.reposDir, "example.com", "acme", "daily")
+	gittest.Run(t, clone, "checkout", "-b", "conflict", "main")
+	if err := os.WriteFile(filepath.Join(clone, "schedule.txt"), []byte("monthly\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gittest.Run(t, clone, "commit", "-am", "monthly")
+	if err := exec.Command("git", "-C", clone, "rebase", testBranch).Run(); err == nil {
+		t.Fatal("expected the rebase to stop on a conflict")
+	}
+	gittest.Run(t, clone, "remote", "set-head", "origin", "--delete")
+	gittest.Run(t, bare, "symbolic-ref", "HEAD", "refs/heads/missing")
+	lock := filepath.Join(clone, ".git", "index.lock")
+	if err := os.WriteFile(lock, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// A recent lock may be held by a running git process, so only an old one is removed
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(lock, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	m.options.Publish = true
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(clone, ".git", "rebase-merge")); !os.IsNotExist(err) {
+		t.Fatalf("expected the rebase to be aborted, got %v", err)
+	}
+	if got := gittest.Run(t, clone, "symbolic-ref", "refs/remotes/origin/HEAD"); got != "refs/remotes/origin/main" {
+		t.Fatalf("expected origin/HEAD to be restored from the API, got %q", got)
+	}
+	if got := gittest.Run(t, bare, "show", testBranch+":schedule.txt"); got != "weekly" {
+		t.Fatalf("expected pushed branch to contain the change, got %q", got)
+	}
+
+	// A detached HEAD is replaced by the default branch, also without a search (e.g. -skip-search without
+	// a previous search)
+	gittest.Run(t, clone, "checkout", "--detach", "origin/main")
+	if _, err := m.identifyEligibleRepos(t.Context(), m.reposDir, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := gittest.Run(t, clone, "symbolic-ref", "--short", "HEAD"); got != "main" {
+		t.Fatalf("expected the default branch to be checked out, got %q", got)
+	}
+}
+
+func TestRunWorkflow_RepairsOnce(t *testing.T) {
+	remotes := gittest.New(t)
+	bare := remotes.Create("acme/daily.git", "main", map[string]string{"schedule.txt": "daily\n"})
+	memory := providertest.NewMemory("example.com", map[string]string{"acme/daily": bare})
+
+	cfg := newTestJobConfig()
+	cfg.Search.Repos = []string{"example.com/acme/daily"}
+
+	m := newWorkflowManagerForTest(t, cfg, &CLIOptions{Shell: "sh", Workers: 2}, memory)
+	logFile := filepath.Join(t.TempDir(), "run.log")
+	logger, err := log.NewLogger(log.Options{LevelDebug: true, LogFile: logFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.log = logger
+
+	// The second run updates the existing clone, which is repaired once per run
+	for range 2 {
+		if err = m.RunWorkflow(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "Repairing example.com/acme/daily"); n != 2 {
+		t.Fatalf("expected the clone to be repaired once per run, got %d repairs in 2 runs", n)
+	}
+}
@@ -1,14 +1,25 @@
 package job
 
 import (
+	"context"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
+	"github.com/fredrikaverpil/multipr/internal/azuredevops"
+	"github.com/fredrikaverpil/multipr/internal/bitbucket"
 	"github.com/fredrikaverpil/multipr/internal/command"
 	"github.com/fredrikaverpil/multipr/internal/config"
+	"github.com/fredrikaverpil/multipr/internal/git"
+	"github.com/fredrikaverpil/multipr/internal/gitea"
+	"github.com/fredrikaverpil/multipr/internal/github"
+	"github.com/fredrikaverpil/multipr/internal/gitlab"
 	"github.com/fredrikaverpil/multipr/internal/log"
+	"github.com/fredrikaverpil/multipr/internal/provider"
+	"github.com/fredrikaverpil/multipr/internal/ratelimit"
 	"github.com/fredrikaverpil/multipr/internal/worker"
 )
 
@@ -22,7 +33,9 @@ type CLIOptions struct {
 	Debug        bool
 	Draft        bool
 	ManualCommit bool
+	Prune        bool
 	Publish      bool
+	PublishMode  string // overrides the publish mode of the job file, e.g. "push"
 	ReviewSteps  bool
 	Shell        string
 	ShowDiffs    bool
@@ -39,11 +52,23 @@ type Manager struct {
 	jobFilePath string
 	log         *log.Logger
 	exec        *command.Executor
+	providers   *provider.Registry
 	pool        *worker.Pool
+	mirrors     *git.Mirrors // nil unless clone.mirror is set
+	summary     runSummary
 }
 
 // NewManager creates a new Runner.
-func NewManager(config *config.JobConfig, opts *CLIOptions, jobFilePath string) (*Manager, error) {
+func NewManager(
+	ctx context.Context,
+	config *config.JobConfig,
+	opts *CLIOptions,
+	jobFilePath string,
+) (*Manager, error) {
+	if err := checkPublishMode(publishMode(config, opts)); err != nil {
+		return nil, err
+	}
+
 	currentDir, err := os.Getwd()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get current working directory: %w", err)
@@ -67,6 +92,18 @@ func NewManager(config *config.JobConfig, opts *CLIOptions, jobFilePath string)
 	logger.Debug("Config loaded: %v", config)
 	exec := command.NewExecutor(opts.Debug, opts.Shell, logger)
 	pool := worker.NewWorkerPool(opts.Workers)
+	providers, err := newProviders(ctx, config, filepath.Dir(jobFilePath), exec, logger)
+	if err != nil {
+		return nil, err
+	}
+	var mirrors *git.Mirrors
+	if config.Clone.Mirror {
+		cacheDir, cacheErr := os.UserCacheDir()
+		if cacheErr != nil {
+			return nil, fmt.Errorf("failed to get cache directory for mirrors: %w", cacheErr)
+		}
+		mirrors = git.NewMirrors(filepath.Join(cacheDir, "multipr", "mirrors"), exec, logger)
+	}
 
 	return &Manager{
 		config:      config,
@@ -76,6 +113,208 @@ func NewManager(config *config.JobConfig, opts *CLIOptions, jobFilePath string)
 		jobFilePath: jobFilePath,
 		log:         logger,
 		exec:        exec,
+		providers:   providers,
 		pool:        pool,
+		mirrors:     mirrors,
 	}, nil
 }
+
+// newProviders registers the hosting providers available to the job, where jobDir is the directory
+// of the job file. Besides the default hosts, the hosts of the search sources and the hosts block are registered.
+func newProviders(
+	ctx context.Context,
+	cfg *config.JobConfig,
+	jobDir string,
+	exec *command.Executor,
+	logger *log.Logger,
+) (*provider.Registry, error) {
+	registry := provider.NewRegistry()
+
+	githubHosts := []string{config.DefaultGitHubHost}
+	for _, source := range cfg.Search.All() {
+		githubHosts = append(githubHosts, source.GitHub.HostOrDefault())
+	}
+	for _, host := range append(githubHosts, hostsOfKind(cfg, provider.KindGitHub)...) {
+		if _, ok := registry.Get(host); ok {
+			continue
+		}
+		// The limiter is shared by all workers, as GitHub rate limits apply per account
+		limiter := ratelimit.New(logger)
+		// The token and git protocol are only resolved once the host is used, as resolving them
+		// may run the GitHub CLI, which e.g. jobs which only target GitLab do not need
+		token := func() string { return githubToken(ctx, cfg, exec, logger, host) }
+		client := github.NewClientWithTokenFunc(github.BaseURL(host), token, limiter)
+		protocol := func() string { return githubGitProtocol(ctx, exec, logger, host) }
+		registry.Register(provider.NewGitHub(host, client, protocol, logger))
+	}
+
+	gitlabHosts := []string{config.DefaultGitLabHost}
+	for _, source := range cfg.Search.All() {
+		gitlabHosts = append(gitlabHosts, source.GitLab.HostOrDefault())
+	}
+	for _, host := range append(gitlabHosts, hostsOfKind(cfg, provider.KindGitLab)...) {
+		if _, ok := registry.Get(host); ok {
+			continue
+		}
+		client := gitlab.NewClient(gitlab.BaseURL(host), hostToken(cfg, host, "GITLAB_TOKEN"))
+		registry.Register(provider.NewGitLab(host, client, logger))
+	}
+
+	azureHosts := []string{config.DefaultAzureDevOpsHost}
+	for _, source := range cfg.Search.All() {
+		azureHosts = append(azureHosts, source.AzureDevOps.HostOrDefault())
+	}
+	for _, host := range append(azureHosts, hostsOfKind(cfg, provider.KindAzureDevOps)...) {
+		if registered(registry, host) {
+			continue
+		}
+		client := azuredevops.NewClient(azuredevops.BaseURL(host), hostToken(cfg, host, "AZURE_DEVOPS_TOKEN"))
+		registry.Register(provider.NewAzureDevOps(host, client, logger))
+	}
+
+	// Gitea and Bitbucket have no default host, so only the configured hosts are registered
+	var localSources []config.LocalSearch
+	giteaHosts := hostsOfKind(cfg, provider.KindGitea)
+	bitbucketHosts := hostsOfKind(cfg, provider.KindBitbucket)
+	for _, source := range cfg.Search.All() {
+		if source.Local.Enabled() {
+			localSources = append(localSources, source.Local)
+		}
+		if source.Gitea.Host != "" {
+			giteaHosts = append(giteaHosts, source.Gitea.Host)
+		}
+		if source.Bitbucket.Host != "" {
+			bitbucketHosts = append(bitbucketHosts, source.Bitbucket.Host)
+		}
+	}
+	for _, host := range giteaHosts {
+		if !registered(registry, host) {
+			client := gitea.NewClient(gitea.BaseURL(host), hostToken(cfg, host, "GITEA_TOKEN"))
+			registry.Register(provider.NewGitea(host, client, logger))
+		}
+	}
+	for _, host := range bitbucketHosts {
+		if !registered(registry, host) {
+			client := bitbucket.NewClient(bitbucket.BaseURL(host), hostToken(cfg, host, "BITBUCKET_TOKEN"))
+			registry.Register(provider.NewBitbucket(host, client, logger))
+		}
+	}
+	if len(localSources) > 0 {
+		registry.Register(provider.NewLocal(jobDir, localSources))
+	}
+
+	// Each listed host must be searched, or declare its kind
+	for _, h := range cfg.Hosts {
+		p, ok := registry.Get(h.Host)
+		switch {
+		case !ok && h.Kind == "":
+			return nil, fmt.Errorf("host %s is not searched, so its kind must be set", h.Host)
+		case !ok:
+			return nil, fmt.Errorf("host %s has an unknown kind %q", h.Host, h.Kind)
+		case h.Kind != "" && p.Kind() != h.Kind:
+			return nil, fmt.Errorf("host %s is of kind %s, but is searched as %s", h.Host, h.Kind, p.Kind())
+		}
+	}
+
+	return registry, nil
+}
+
+// hostsOfKind returns the hosts of the hosts block of the given kind.
+func hostsOfKind(cfg *config.JobConfig, kind string) []string {
+	var hosts []string
+	for _, h := range cfg.Hosts {
+		if h.Kind == kind {
+			hosts = append(hosts, h.Host)
+		}
+	}
+	return hosts
+}
+
+// hostToken returns the token of the host from the token_env of the hosts block,
+// or else from the provider's default environment variable.
+func hostToken(cfg *config.JobConfig, host, defaultEnv string) string {
+	if h, ok := cfg.Host(host); ok && h.TokenEnv != "" {
+		return os.Getenv(h.TokenEnv)
+	}
+	return os.Getenv(defaultEnv)
+}
+
+// configureRepo sets the git environment of the repository's host and the clone options of the job.
+// The environment of the hosts block is added after the provider's credentials, so that it takes precedence.
+func (m *Manager) configureRepo(repo *git.Repo) {
+	repo.Env = m.hostEnv(repo.Host)
+	if creds, ok := repo.Provider().(provider.GitCredentials); ok {
+		repo.Env = append(creds.GitEnv(), repo.Env...)
+	}
+	repo.CloneOptions = m.config.Clone
+	repo.Mirrors = m.mirrors
+}
+
+// hostEnv returns the environment of git commands against the host, as "KEY=value" pairs.
+func (m *Manager) hostEnv(host string) []string {
+	h, _ := m.config.Host(host)
+	env := make([]string, 0, len(h.Env))
+	for _, key := range slices.Sorted(maps.Keys(h.Env)) {
+		env = append(env, key+"="+h.Env[key])
+	}
+	return env
+}
+
+func registered(registry *provider.Registry, host string) bool {
+	_, ok := registry.Get(host)
+	return ok
+}
+
+// githubToken returns the token of the host from the token_env of the hosts block, or else from the same
+// environment variables as the GitHub CLI (GH_TOKEN or GITHUB_TOKEN for github.com, GH_ENTERPRISE_TOKEN or
+// GITHUB_ENTERPRISE_TOKEN for other hosts), or else the token the GitHub CLI is authenticated with for the host.
+func githubToken(
+	ctx context.Context,
+	cfg *config.JobConfig,
+	exec *command.Executor,
+	logger *log.Logger,
+	host string,
+) string {
+	if h, ok := cfg.Host(host); ok && h.TokenEnv != "" {
+		return os.Getenv(h.TokenEnv)
+	}
+
+	envs := []string{"GH_TOKEN", "GITHUB_TOKEN"}
+	if host != github.DefaultHost {
+		envs = []string{"GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN"}
+	}
+	for _, env := range envs {
+		if token := os.Getenv(env); token != "" {
+			return token
+		}
+	}
+
+	token, err := exec.GHAuthToken(ctx, host)
+	if err != nil {
+		logger.Debug(fmt.Sprintf("No GitHub token found for %s, using unauthenticated requests: %v", host, err))
+		return ""
+	}
+	return token
+}
+
+// githubGitProtocol returns the protocol the GitHub CLI is configured to clone the host's repositories with,
+// or else HTTPS.
+func githubGitProtocol(ctx context.Context, exec *command.Executor, logger *log.Logger, host string) string {
+	protocol, err := exec.GHGitProtocol(ctx, host)
+	if err != nil {
+		logger.Debug(fmt.Sprintf("No gh git_protocol found for %s, cloning over HTTPS: %v", host, err))
+		return provider.GitProtocolHTTPS
+	}
+	if strings.TrimSpace(protocol) == provider.GitProtocolSSH {
+		return provider.GitProtocolSSH
+	}
+	return provider.GitProtocolHTTPS
+}
+
+// prConfig returns the pull request configuration for the repository's host, or else its provider.
+func (m *Manager) prConfig(repo *git.Repo) config.PullRequest {
+	if h, ok := m.config.Host(repo.Host); ok && h.PR != nil {
+		return *h.PR
+	}
+	return m.config.PR.For(repo.Provider().Kind())
+}
@@ -0,0 +1,236 @@
+package job //nolint:testpackage // internal testing needed for unexported functions
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fredrikaverpil/multipr/internal/command"
+	"github.com/fredrikaverpil/multipr/internal/config"
+	"github.com/fredrikaverpil/multipr/internal/log"
+	"github.com/fredrikaverpil/multipr/internal/provider"
+)
+
+func TestNewProviders(t *testing.T) {
+	t.Setenv("GH_TOKEN", "github-token")
+	t.Setenv("GH_ENTERPRISE_TOKEN", "enterprise-token")
+
+	logger, err := log.NewLogger(log.Options{LevelDebug: false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec := command.NewExecutor(false, "sh", logger)
+
+	cfg := &config.JobConfig{}
+	cfg.Search.Sources = []config.SearchSource{
+		{GitHub: config.GitHubSearch{Host: "ghe.example.com", Method: "repos", Query: "--owner acme"}},
+		{GitLab: config.GitLabSearch{Host: "gitlab.example.com", Group: "platform"}},
+		{GitHub: config.GitHubSearch{Method: "repos", Query: "--owner acme"}},
+		{Gitea: config.GiteaSearch{Host: "forgejo.example.com", Org: "tooling"}},
+		{Bitbucket: config.BitbucketSearch{Host: "bitbucket.example.com", Projects: []string{"LEG"}}},
+		{AzureDevOps: config.AzureDevOpsSearch{Host: "tfs.example.com", Organization: "DefaultCollection"}},
+	}
+	// Hosts which are not searched are registered by kind, e.g. to publish to them
+	cfg.Hosts = []config.Host{
+		{Host: "ghe.example.com", TokenEnv: "GHE_TOKEN"},
+		{Host: "codeberg.example.com", Kind: provider.KindGitea},
+	}
+
+	registry, err := newProviders(t.Context(), cfg, t.TempDir(), exec, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		host     string
+		kind     string
+		fullName string // defaults to acme/api
+		cloneURL string
+	}{
+		{host: "github.com", kind: provider.KindGitHub, cloneURL: "https://github.com/acme/api.git"},
+		{host: "ghe.example.com", kind: provider.KindGitHub, cloneURL: "https://ghe.example.com/acme/api.git"},
+		{host: "gitlab.com", kind: provider.KindGitLab, cloneURL: "https://gitlab.com/acme/api.git"},
+		{host: "gitlab.example.com", kind: provider.KindGitLab, cloneURL: "https://gitlab.example.com/acme/api.git"},
+		{host: "forgejo.example.com", kind: provider.KindGitea, cloneURL: "https://forgejo.example.com/acme/api.git"},
+		{host: "codeberg.example.com", kind: provider.KindGitea, cloneURL: "https://codeberg.example.com/acme/api.git"},
+		{
+			host:     "bitbucket.example.com",
+			kind:     provider.KindBitbucket,
+			cloneURL: "https://bitbucket.example.com/scm/acme/api.git",
+		},
+		{
+			host:     "dev.azure.com",
+			kind:     provider.KindAzureDevOps,
+			fullName: "acme/Platform/api",
+			cloneURL: "https://dev.azure.com/acme/Platform/_git/api",
+		},
+		{
+			host:     "tfs.example.com",
+			kind:     provider.KindAzureDevOps,
+			fullName: "DefaultCollection/Platform/api",
+			cloneURL: "https://tfs.example.com/DefaultCollection/Platform/_git/api",
+		},
+	}
+	for _, tt := range tests {
+		p, ok := registry.Get(tt.host)
+		if !ok {
+			t.Fatalf("expected a provider for %s", tt.host)
+		}
+		if p.Kind() != tt.kind {
+			t.Errorf("%s: expected kind %s, got %s", tt.host, tt.kind, p.Kind())
+		}
+		fullName := tt.fullName
+		if fullName == "" {
+			fullName = "acme/api"
+		}
+		if got := p.CloneURL(fullName); got != tt.cloneURL {
+			t.Errorf("%s: expected clone URL %s, got %s", tt.host, tt.cloneURL, got)
+		}
+	}
+}
+
+func TestNewProviders_ResolvesGitHubLazily(t *testing.T) {
+	for _, env := range []string{"GH_TOKEN", "GITHUB_TOKEN"} {
+		t.Setenv(env, "")
+	}
+	// A fake GitHub CLI records its calls
+	bin := t.TempDir()
+	calls := filepath.Join(bin, "calls")
+	script := "#!/bin/sh\necho \"$*\" >> " + calls + "\n" +
+		`case "$1" in auth) echo gh-secret-token ;; config) echo ssh ;; esac` + "\n"
+	if err := os.WriteFile(filepath.Join(bin, "gh"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	logger, err := log.NewLogger(log.Options{LevelDebug: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec := command.NewExecutor(true, "sh", logger)
+
+	cfg := &config.JobConfig{}
+	cfg.Search.GitLab = config.GitLabSearch{Group: "platform"}
+	registry, err := newProviders(t.Context(), cfg, t.TempDir(), exec, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Jobs which do not use GitHub do not need the GitHub CLI
+	if _, statErr := os.Stat(calls); !os.IsNotExist(statErr) {
+		t.Fatalf("expected the GitHub CLI not to be called, got %v", statErr)
+	}
+
+	p, ok := registry.Get("github.com")
+	if !ok {
+		t.Fatal("expected a provider for github.com")
+	}
+	stdout := captureStdout(t, func() {
+		for range 2 {
+			if got := p.CloneURL("acme/api"); got != "git@github.com:acme/api.git" {
+				t.Errorf("expected an SSH clone URL, got %s", got)
+			}
+			if got := p.(provider.GitCredentials).GitEnv(); len(got) == 0 {
+				t.Error("expected the token to be passed to git")
+			}
+		}
+	})
+	// The token is not printed, not even in debug mode
+	if strings.Contains(stdout, "gh-secret-token") {
+		t.Fatalf("expected the token not to be printed, got:\n%s", stdout)
+	}
+
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "config get git_protocol --host github.com\nauth token --hostname github.com\n"
+	if string(data) != want {
+		t.Fatalf("expected the GitHub CLI to be called once per value, got:\n%s", data)
+	}
+}
+
+// captureStdout returns what fn writes to stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	out := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- string(data)
+	}()
+	fn()
+	_ = w.Close()
+	return <-out
+}
+
+func TestNewProviders_InvalidHosts(t *testing.T) {
+	logger, err := log.NewLogger(log.Options{LevelDebug: false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec := command.NewExecutor(false, "sh", logger)
+
+	tests := []struct {
+		name string
+		host config.Host
+	}{
+		{name: "missing kind", host: config.Host{Host: "git.example.com"}},
+		{name: "unknown kind", host: config.Host{Host: "git.example.com", Kind: "svn"}},
+		{name: "kind mismatch", host: config.Host{Host: "github.com", Kind: provider.KindGitLab}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.JobConfig{Hosts: []config.Host{tt.host}}
+			if _, err := newProviders(t.Context(), cfg, t.TempDir(), exec, logger); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestNewManager_PublishMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    string // of the job file
+		flag    string
+		wantErr bool
+	}{
+		{name: "default"},
+		{name: "pr", mode: config.PublishModePR},
+		{name: "push", mode: config.PublishModePush},
+		{name: "gerrit", mode: config.PublishModeGerrit},
+		{name: "email", mode: config.PublishModeEmail},
+		{name: "flag overrides job file", mode: "mail", flag: config.PublishModeEmail},
+		{name: "unknown mode", mode: "mail", wantErr: true},
+		{name: "unknown flag", mode: config.PublishModePR, flag: "merge", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.JobConfig{Publish: config.Publish{Mode: tt.mode}}
+			err := checkPublishMode(publishMode(cfg, &CLIOptions{PublishMode: tt.flag}))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	// The mode is checked before anything is created for the job
+	t.Chdir(t.TempDir())
+	cfg := &config.JobConfig{Name: "invalid", Publish: config.Publish{Mode: "mail"}}
+	if _, err := NewManager(t.Context(), cfg, &CLIOptions{}, "job.yml"); err == nil {
+		t.Fatal("expected an error for an unknown publish mode")
+	}
+	if _, err := os.Stat("jobs"); !os.IsNotExist(err) {
+		t.Fatalf("expected no job work dir, got %v", err)
+	}
+}
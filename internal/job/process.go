@@ -18,7 +18,7 @@ func (m *Manager) processRepository(ctx context.Context, repo *git.Repo) error {
 	}
 
 	// Create new branch for changes
-	branchName := m.config.PR.GitHub.Branch
+	branchName := m.prConfig(repo).Branch
 	if err := repo.CheckoutNewBranch(ctx, branchName); err != nil {
 		return fmt.Errorf("failed to create branch for %s: %w", repo.LocalPath(), err)
 	}
@@ -81,7 +81,7 @@ func (m *Manager) handleStagingAndCommits(ctx context.Context, repo *git.Repo) e
 		_, err := m.exec.Execute(
 			ctx,
 			"git",
-			[]string{"commit", "-m", m.config.PR.GitHub.Title},
+			[]string{"commit", "-m", m.prConfig(repo).Title},
 			command.WithDir(repo.LocalPath()),
 		)
 		if err != nil {
@@ -103,6 +103,7 @@ func (m *Manager) processRepositories(ctx context.Context, repos []*git.Repo) ([
 		m.pool.Submit(func() {
 			err := m.processRepository(ctx, repo)
 			if err != nil {
+				m.summary.record(repo, outcomeFailed, err.Error())
 				mu.Lock()
 				errs = append(errs, err)
 				mu.Unlock()
@@ -110,6 +111,7 @@ func (m *Manager) processRepositories(ctx context.Context, repos []*git.Repo) ([
 			}
 
 			// Add to processed repos list
+			m.summary.record(repo, outcomeChanged, "")
 			mu.Lock()
 			processedRepos = append(processedRepos, repo)
 			mu.Unlock()
@@ -0,0 +1,69 @@
+package job
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fredrikaverpil/multipr/internal/git"
+)
+
+// reconcileRepos splits the cloned repositories into those which are part of the search result,
+// and stale ones which no longer match the search (e.g. because the query changed or they were renamed).
+func reconcileRepos(cloned, searched []*git.Repo) ([]*git.Repo, []*git.Repo) {
+	searchedNames := make(map[string]struct{}, len(searched))
+	for _, repo := range searched {
+		searchedNames[repo.String()] = struct{}{}
+	}
+
+	var current, stale []*git.Repo
+	for _, repo := range cloned {
+		if _, ok := searchedNames[repo.String()]; ok {
+			current = append(current, repo)
+			continue
+		}
+		stale = append(stale, repo)
+	}
+	return current, stale
+}
+
+// handleStaleRepos reports clones which are no longer matched by the search,
+// and removes them when pruning is enabled.
+func (m *Manager) handleStaleRepos(stale []*git.Repo) error {
+	if len(stale) == 0 {
+		return nil
+	}
+
+	m.log.Info(fmt.Sprintf("Skipping %d cloned repositories no longer matched by the search", len(stale)))
+	for _, repo := range stale {
+		m.log.Info(fmt.Sprintf("  - %s", repo.String()))
+	}
+
+	if !m.options.Prune {
+		m.log.Info("☂️ To remove them, use the -prune flag")
+		return nil
+	}
+	if m.options.ReviewSteps && !m.confirmStep(fmt.Sprintf("Remove %d stale repositories?", len(stale))) {
+		return nil
+	}
+
+	for _, repo := range stale {
+		m.log.Info(fmt.Sprintf("Removing %s", repo.LocalPath()))
+		if err := os.RemoveAll(repo.LocalPath()); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", repo.LocalPath(), err)
+		}
+		removeEmptyParents(filepath.Dir(repo.LocalPath()), m.reposDir)
+	}
+	return nil
+}
+
+// removeEmptyParents removes dir and its parents while they are empty, stopping at root.
+func removeEmptyParents(dir, root string) {
+	for dir != root && len(dir) > len(root) {
+		// os.Remove fails on non-empty directories, which ends the walk
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
@@ -0,0 +1,91 @@
+package job //nolint:testpackage // internal testing needed for unexported functions
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fredrikaverpil/multipr/internal/config"
+	"github.com/fredrikaverpil/multipr/internal/gittest"
+	"github.com/fredrikaverpil/multipr/internal/provider/providertest"
+)
+
+func TestRunWorkflow_StaleClones(t *testing.T) {
+	remotes := gittest.New(t)
+	memory := providertest.NewMemory("example.com", map[string]string{
+		"acme/daily":       remotes.Create("acme/daily.git", "main", map[string]string{"schedule.txt": "daily\n"}),
+		"acme/team/hourly": remotes.Create("acme/team/hourly.git", "main", map[string]string{"schedule.txt": "daily\n"}),
+	})
+
+	cfg := newTestJobConfig()
+	cfg.Search.Repos = []string{"example.com/acme/daily", "example.com/acme/team/hourly"}
+
+	opts := &CLIOptions{Shell: "sh", Workers: 2}
+	m := newWorkflowManagerForTest(t, cfg, opts, memory)
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	stalePath := filepath.Join(m.reposDir, "example.com", "acme", "team", "hourly")
+	if _, err := os.Stat(stalePath); err != nil {
+		t.Fatalf("expected clone to exist: %v", err)
+	}
+
+	// Without -prune, the clone which is no longer matched is skipped but kept
+	cfg.Search.Filters.Exclude = []string{"acme/team/*"}
+	opts.Publish = true
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(memory.PRs("acme/team/hourly")); n != 0 {
+		t.Fatalf("expected no PR for the stale clone, got %d", n)
+	}
+	if n := len(memory.PRs("acme/daily")); n != 1 {
+		t.Fatalf("expected 1 PR for acme/daily, got %d", n)
+	}
+	if _, err := os.Stat(stalePath); err != nil {
+		t.Fatalf("expected stale clone to be kept: %v", err)
+	}
+
+	// With -prune, the clone and its empty namespace directory are removed
+	opts.Prune = true
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Dir(stalePath)); !os.IsNotExist(err) {
+		t.Fatalf("expected stale clone to be removed, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(m.reposDir, "example.com", "acme", "daily")); err != nil {
+		t.Fatalf("expected matched clone to be kept: %v", err)
+	}
+}
+
+func TestRunWorkflow_NoSearch(t *testing.T) {
+	remotes := gittest.New(t)
+	memory := providertest.NewMemory("example.com", map[string]string{
+		"acme/daily": remotes.Create("acme/daily.git", "main", map[string]string{"schedule.txt": "daily\n"}),
+	})
+
+	cfg := newTestJobConfig()
+	cfg.Search.Repos = []string{"example.com/acme/daily"}
+
+	opts := &CLIOptions{Shell: "sh", Workers: 2}
+	m := newWorkflowManagerForTest(t, cfg, opts, memory)
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a search block, all existing clones are processed, and none of them is stale
+	cfg.Search = config.Search{}
+	opts.Publish = true
+	opts.Prune = true
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(m.reposDir, "example.com", "acme", "daily")); err != nil {
+		t.Fatalf("expected the clone to be kept: %v", err)
+	}
+	if n := len(memory.PRs("acme/daily")); n != 1 {
+		t.Fatalf("expected 1 PR for acme/daily, got %d", n)
+	}
+}
@@ -6,59 +6,90 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 
-	"github.com/fredrikaverpil/multipr/internal/command"
+	"github.com/fredrikaverpil/multipr/internal/config"
 	"github.com/fredrikaverpil/multipr/internal/git"
+	"github.com/fredrikaverpil/multipr/internal/provider"
 )
 
+// publishModes are the supported publish modes.
+var publishModes = []string{
+	config.PublishModePR,
+	config.PublishModePush,
+	config.PublishModeGerrit,
+	config.PublishModeEmail,
+}
+
+// publishMode returns the publish mode, where the -publish-mode flag takes precedence over the job file.
+func publishMode(cfg *config.JobConfig, opts *CLIOptions) string {
+	if opts.PublishMode != "" {
+		return opts.PublishMode
+	}
+	return cfg.Publish.ModeOrDefault()
+}
+
+func (m *Manager) publishMode() string {
+	return publishMode(m.config, m.options)
+}
+
+// checkPublishMode returns an error if the publish mode is not supported, so that a job does not fail
+// only after its changes were made.
+func checkPublishMode(mode string) error {
+	if !slices.Contains(publishModes, mode) {
+		return fmt.Errorf("unknown publish mode %q, expected one of: %s", mode, strings.Join(publishModes, ", "))
+	}
+	return nil
+}
+
 func (m *Manager) publishRepositories(ctx context.Context, repos []*git.Repo) error {
 	var mu sync.Mutex
 	var errs []error
+	var pushed []pushRecord
 
 	if !m.options.Publish {
 		return nil
 	}
 
-	m.log.Info("Publishing PRs for repositories...")
+	mode := m.publishMode()
+	switch mode {
+	case config.PublishModePR:
+		m.log.Info("Publishing PRs for repositories...")
+	case config.PublishModePush:
+		m.log.Info("Pushing branches of repositories...")
+	case config.PublishModeGerrit:
+		m.log.Info("Uploading changes of repositories to Gerrit...")
+	case config.PublishModeEmail:
+		m.log.Info("Formatting patch series of repositories...")
+	default:
+		return fmt.Errorf("unknown publish mode %q", mode)
+	}
 
 	for _, repo := range repos {
 		m.pool.Submit(func() {
-			// Push branch to remote
-			if err := repo.PushBranch(ctx, m.config.PR.GitHub.Branch); err != nil {
-				mu.Lock()
-				errs = append(errs, fmt.Errorf("failed to push branch for %s: %w", repo.LocalPath(), err))
-				mu.Unlock()
-				return
-			}
-
-			// Check if PR already exists
-			exists, prNumber, err := repo.CheckPRExists(ctx, m.config.PR.GitHub.Branch)
+			record, err := m.publishRepository(ctx, repo, mode)
+			mu.Lock()
+			defer mu.Unlock()
 			if err != nil {
-				mu.Lock()
-				errs = append(errs, fmt.Errorf("failed to check if PR exists for %s: %w", repo.LocalPath(), err))
-				mu.Unlock()
-				return
-			}
-
-			var processErr error
-			if exists {
-				processErr = m.updateExistingPR(ctx, repo, prNumber)
-			} else {
-				processErr = m.createNewPR(ctx, repo)
+				m.summary.record(repo, outcomeFailed, err.Error())
+				errs = append(errs, err)
 			}
-
-			if processErr != nil {
-				mu.Lock()
-				errs = append(errs, processErr)
-				mu.Unlock()
+			if record != nil {
+				pushed = append(pushed, *record)
 			}
 		})
 	}
 
 	m.pool.Wait()
 
+	if len(pushed) > 0 {
+		if err := m.savePushRecords(pushed); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
 	if len(errs) > 0 {
 		return errors.Join(errs...)
 	}
@@ -66,36 +97,82 @@ func (m *Manager) publishRepositories(ctx context.Context, repos []*git.Repo) er
 	return nil
 }
 
-func (m *Manager) updateExistingPR(ctx context.Context, repo *git.Repo, prNumber string) error {
+// publishRepository pushes the branch and opens or updates its pull request.
+// In push mode, and for push-only providers (e.g. local repositories), the branch is only pushed
+// and the returned record holds its compare URL. In gerrit mode, the commit is uploaded as a change instead,
+// and in email mode the commits are formatted (and sent) as a patch series without pushing anything.
+func (m *Manager) publishRepository(ctx context.Context, repo *git.Repo, mode string) (*pushRecord, error) {
+	switch mode {
+	case config.PublishModeGerrit:
+		return nil, m.uploadChange(ctx, repo)
+	case config.PublishModeEmail:
+		return nil, m.mailPatches(ctx, repo)
+	}
+
+	pr := m.prConfig(repo)
+
+	// Push branch to remote
+	if err := repo.PushBranch(ctx, pr.Branch); err != nil {
+		return nil, fmt.Errorf("failed to push branch for %s: %w", repo.LocalPath(), err)
+	}
+
+	if mode == config.PublishModePush || provider.IsPushOnly(repo.Provider()) {
+		return m.recordPush(ctx, repo, pr.Branch)
+	}
+
+	// Check if PR already exists
+	existing, err := repo.FindPR(ctx, pr.Branch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if PR exists for %s: %w", repo.LocalPath(), err)
+	}
+
+	if existing != nil {
+		return nil, m.updateExistingPR(ctx, repo, existing)
+	}
+	return nil, m.createNewPR(ctx, repo)
+}
+
+// recordPush logs the pushed branch, with the URL to compare it with the default branch where available.
+func (m *Manager) recordPush(ctx context.Context, repo *git.Repo, branch string) (*pushRecord, error) {
+	base, err := repo.DefaultBranch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get default branch of %s: %w", repo.LocalPath(), err)
+	}
+
+	compareURL := repo.Provider().CompareURL(repo.FullName, base, branch)
+	if compareURL != "" {
+		m.log.Info(fmt.Sprintf("Pushed branch %s of %s: %s", branch, repo.String(), compareURL))
+	} else {
+		m.log.Info(fmt.Sprintf("Pushed branch %s to %s", branch, repo.Provider().CloneURL(repo.FullName)))
+	}
+	m.summary.record(repo, outcomePushed, compareURL)
+
+	return &pushRecord{Repository: repo.String(), Branch: branch, Base: base, CompareURL: compareURL}, nil
+}
+
+func (m *Manager) updateExistingPR(ctx context.Context, repo *git.Repo, existing *provider.PullRequest) error {
 	repoName := filepath.Base(repo.LocalPath())
-	m.log.Info(fmt.Sprintf("Editing existing PR #%s for %s", prNumber, repoName))
+	m.log.Info(fmt.Sprintf("Editing existing PR #%d for %s", existing.Number, repoName))
 
-	processedBody := m.processBodyTemplate(m.config.PR.GitHub.Body)
+	opts := m.prOptions(repo)
 
-	_, err := m.exec.Execute(
-		ctx,
-		"gh",
-		[]string{"pr", "edit", prNumber, "--title", m.config.PR.GitHub.Title, "--body", processedBody},
-		command.WithDir(repo.LocalPath()),
-	)
+	err := repo.Provider().EditPR(ctx, repo.FullName, existing, opts)
 	if err != nil {
 		return fmt.Errorf("failed to edit PR for %s: %w", repo.LocalPath(), err)
 	}
 
-	var draftCmd string
 	if m.options.Draft {
-		m.log.Info(fmt.Sprintf("Marking existing PR #%s as draft", prNumber))
-		draftCmd = fmt.Sprintf("gh pr ready --undo %s", prNumber)
+		m.log.Info(fmt.Sprintf("Marking existing PR #%d as draft", existing.Number))
 	} else {
-		m.log.Info(fmt.Sprintf("Marking existing PR #%s as ready for review", prNumber))
-		draftCmd = fmt.Sprintf("gh pr ready %s", prNumber)
+		m.log.Info(fmt.Sprintf("Marking existing PR #%d as ready for review", existing.Number))
 	}
 
-	_, err = m.exec.ExecuteWithShell(ctx, draftCmd, "", command.WithDir(repo.LocalPath()))
+	err = repo.Provider().SetDraft(ctx, repo.FullName, existing, m.options.Draft)
 	if err != nil {
 		return fmt.Errorf("failed to update PR draft status for %s: %w", repo.LocalPath(), err)
 	}
 
+	m.summary.record(repo, outcomePRUpdated, existing.URL)
 	return nil
 }
 
@@ -103,31 +180,27 @@ func (m *Manager) createNewPR(ctx context.Context, repo *git.Repo) error {
 	repoName := filepath.Base(repo.LocalPath())
 	m.log.Info(fmt.Sprintf("Creating PR for %s", repoName))
 
-	processedBody := m.processBodyTemplate(m.config.PR.GitHub.Body)
-
-	args := []string{
-		"pr",
-		"create",
-		"--assignee",
-		"@me",
-		"--title",
-		m.config.PR.GitHub.Title,
-		"--body",
-		processedBody,
-	}
-
-	if m.options.Draft {
-		args = append(args, "--draft")
-	}
-
-	_, err := m.exec.Execute(ctx, "gh", args, command.WithDir(repo.LocalPath()))
+	pr, err := repo.Provider().CreatePR(ctx, repo.FullName, m.prOptions(repo))
 	if err != nil {
 		return fmt.Errorf("failed to create PR for %s: %w", repo.LocalPath(), err)
 	}
 
+	m.summary.record(repo, outcomePRCreated, pr.URL)
 	return nil
 }
 
+// prOptions returns the desired pull request state for the repository.
+func (m *Manager) prOptions(repo *git.Repo) provider.PROptions {
+	pr := m.prConfig(repo)
+	return provider.PROptions{
+		Title:     pr.Title,
+		Body:      m.processBodyTemplate(pr.Body),
+		Branch:    pr.Branch,
+		Draft:     m.options.Draft,
+		Reviewers: pr.Reviewers,
+	}
+}
+
 const yamlPlaceholder = "{yaml}"
 
 // processBodyTemplate replaces {yaml} placeholder with the job YAML content.
@@ -1,13 +1,17 @@
 package job //nolint:testpackage // internal testing needed for unexported methods
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/fredrikaverpil/multipr/internal/command"
+	"github.com/fredrikaverpil/multipr/internal/config"
+	"github.com/fredrikaverpil/multipr/internal/gittest"
 	"github.com/fredrikaverpil/multipr/internal/log"
+	"github.com/fredrikaverpil/multipr/internal/provider/providertest"
 )
 
 func newManagerForTest(t *testing.T, jobContent string) *Manager {
@@ -166,3 +170,54 @@ func TestProcessBodyTemplate_NoTrailingNewlineInYAML(t *testing.T) {
 		t.Fatalf("mismatch:\n--- got ---\n%s\n--- want ---\n%s", got, want)
 	}
 }
+
+func TestRunWorkflow_PushMode(t *testing.T) {
+	remotes := gittest.New(t)
+	memory := providertest.NewMemory("example.com", map[string]string{
+		"acme/daily": remotes.Create("acme/daily.git", "main", map[string]string{"schedule.txt": "daily\n"}),
+	})
+
+	cfg := newTestJobConfig()
+	cfg.Search.Repos = []string{"example.com/acme/daily"}
+	cfg.Publish.Mode = config.PublishModePush
+
+	m := newWorkflowManagerForTest(t, cfg, &CLIOptions{Publish: true, Shell: "sh", Workers: 2}, memory)
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The branch is pushed without a pull request
+	if got := remotes.File("acme/daily.git", testBranch, "schedule.txt"); got != "weekly" {
+		t.Fatalf("expected pushed branch to contain the change, got %q", got)
+	}
+	if n := len(memory.PRs("acme/daily")); n != 0 {
+		t.Fatalf("expected no PR in push mode, got %d", n)
+	}
+
+	data, err := os.ReadFile(filepath.Join(m.workDir, pushRecordsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records pushRecords
+	if err = json.Unmarshal(data, &records); err != nil {
+		t.Fatal(err)
+	}
+	want := pushRecord{
+		Repository: "example.com/acme/daily",
+		Branch:     testBranch,
+		Base:       "main",
+		CompareURL: "memory://example.com/acme/daily/compare/main..." + testBranch,
+	}
+	if len(records.Branches) != 1 || records.Branches[0] != want {
+		t.Fatalf("unexpected pushed branches: %+v", records.Branches)
+	}
+
+	// The -publish-mode flag overrides the job file
+	m.options.PublishMode = config.PublishModePR
+	if err = m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(memory.PRs("acme/daily")); n != 1 {
+		t.Fatalf("expected a PR in pr mode, got %d", n)
+	}
+}
@@ -0,0 +1,55 @@
+package job
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fredrikaverpil/multipr/internal/log"
+)
+
+const pushRecordsFile = "pushed.json"
+
+// pushRecord is a branch which was pushed without opening a pull request.
+type pushRecord struct {
+	Repository string `json:"repository"` // e.g. "github.com/owner/repo"
+	Branch     string `json:"branch"`
+	Base       string `json:"base"`                  // the default branch
+	CompareURL string `json:"compare_url,omitempty"` // empty for providers without a web interface
+}
+
+// pushRecords is the persisted list of pushed branches, stored in the job work dir.
+type pushRecords struct {
+	Time     time.Time    `json:"time"`
+	Branches []pushRecord `json:"branches"`
+}
+
+func (m *Manager) pushRecordsPath() string {
+	return filepath.Join(m.workDir, pushRecordsFile)
+}
+
+// savePushRecords persists the branches pushed by the latest run, replacing those of previous runs.
+func (m *Manager) savePushRecords(records []pushRecord) error {
+	records = slices.Clone(records)
+	slices.SortFunc(records, func(a, b pushRecord) int {
+		return strings.Compare(a.Repository, b.Repository)
+	})
+
+	data, err := json.MarshalIndent(pushRecords{Time: time.Now().UTC(), Branches: records}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode pushed branches: %w", err)
+	}
+
+	if err = os.MkdirAll(m.workDir, DefaultFilePerms); err != nil {
+		return fmt.Errorf("failed to create job directory: %w", err)
+	}
+	if err = os.WriteFile(m.pushRecordsPath(), data, log.RegularFilePerms); err != nil {
+		return fmt.Errorf("failed to write pushed branches: %w", err)
+	}
+	m.log.Info(fmt.Sprintf("Recorded %d pushed branches in %s", len(records), m.pushRecordsPath()))
+	return nil
+}
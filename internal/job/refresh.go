@@ -0,0 +1,202 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fredrikaverpil/multipr/internal/git"
+	"github.com/fredrikaverpil/multipr/internal/provider"
+)
+
+// refreshRepositories queries the metadata of the repositories before they are cloned or updated.
+// Renamed (or transferred) repositories continue under their new name, and their clones are moved along.
+// Archived and disabled repositories cannot be pushed to, so they are skipped and left out of the result,
+// unless the search asks for archived repositories.
+func (m *Manager) refreshRepositories(ctx context.Context, repos []*git.Repo) ([]*git.Repo, error) {
+	m.log.Info("Refreshing repository metadata...")
+
+	if err := os.MkdirAll(m.reposDir, DefaultFilePerms); err != nil {
+		return nil, fmt.Errorf("failed to create repositories directory: %w", err)
+	}
+
+	metas := m.queryMetadata(ctx, repos)
+	if err := m.storeSearchMetadata(repos, metas); err != nil {
+		return nil, err
+	}
+
+	// Clones are moved one at a time, as moving one can remove the (empty) parent directory of another
+	var current []*git.Repo
+	seen := make(map[string]bool, len(repos))
+	for i, repo := range repos {
+		meta := metas[i]
+		if meta != nil && renamed(repo, meta) {
+			if err := m.followRename(ctx, repo, meta.FullName); err != nil {
+				return nil, err
+			}
+		}
+		// e.g. listed under its old name, and found by a search under its new name
+		if seen[repo.String()] {
+			continue
+		}
+		seen[repo.String()] = true
+
+		if meta == nil {
+			current = append(current, repo)
+			continue
+		}
+		if reason := skipReason(meta, m.config.Search.Filters.Archived); reason != "" {
+			m.log.Info(fmt.Sprintf("Skipping %s: %s", repo.String(), reason))
+			m.summary.record(repo, outcomeSkipped, reason)
+			continue
+		}
+		current = append(current, repo)
+	}
+
+	if err := m.moveRenamedClones(ctx, repos); err != nil {
+		return nil, err
+	}
+	return current, nil
+}
+
+// queryMetadata returns the metadata of each repository, or nil where it could not be queried.
+// Those repositories are used as they are, so that e.g. cloning them reports what is wrong.
+// The metadata returned by the search (or stored with it, with -skip-search) is used without querying it again.
+// Searches without metadata (e.g. code search, or listed repositories) cost one API request per repository.
+func (m *Manager) queryMetadata(ctx context.Context, repos []*git.Repo) []*provider.Repository {
+	metas := make([]*provider.Repository, len(repos))
+	for i, repo := range repos {
+		if repo.Metadata != nil {
+			metas[i] = repo.Metadata
+			continue
+		}
+		m.pool.Submit(func() {
+			meta, err := repo.Provider().Repository(ctx, repo.FullName)
+			if err != nil {
+				m.log.Warn(fmt.Sprintf("Failed to query metadata of %s: %v", repo.String(), err))
+				return
+			}
+			metas[i] = meta
+		})
+	}
+	m.pool.Wait()
+	return metas
+}
+
+// renamed reports whether the repository has another name now. Names only differing in case are the same
+// on most hosting services, and would be the same path on case-insensitive file systems.
+func renamed(repo *git.Repo, meta *provider.Repository) bool {
+	return !strings.EqualFold(meta.FullName, repo.FullName)
+}
+
+// skipReason returns why the repository is skipped, or an empty string. Archived repositories are kept when
+// the archived filter asks for them, e.g. to only identify them, but pushing to them fails.
+func skipReason(meta *provider.Repository, archived *bool) string {
+	switch {
+	case meta.Disabled:
+		return "repository is disabled"
+	case meta.Archived && (archived == nil || !*archived):
+		return "repository is archived"
+	}
+	return ""
+}
+
+// followRename renames the repository, moving its clone if it was cloned under the old name.
+func (m *Manager) followRename(ctx context.Context, repo *git.Repo, fullName string) error {
+	m.log.Info(fmt.Sprintf("Repository %s was renamed or transferred to %s", repo.String(), fullName))
+
+	oldPath := repo.LocalPath()
+	repo.FullName = fullName
+	if _, err := os.Stat(oldPath); err != nil {
+		return nil
+	}
+	return m.moveClone(ctx, repo, oldPath)
+}
+
+// moveRenamedClones moves the clones of repositories which were renamed since they were cloned, but which
+// are found under their new name (e.g. by a search), so that they are not cloned again.
+// Only clones which are not part of the result are looked up, and only when a repository is not cloned yet,
+// as a clone can only be the renamed clone of such a repository.
+func (m *Manager) moveRenamedClones(ctx context.Context, repos []*git.Repo) error {
+	wanted := make(map[string]bool, len(repos))
+	uncloned := false
+	for _, repo := range repos {
+		wanted[repo.String()] = true
+		if _, err := os.Stat(repo.LocalPath()); err != nil {
+			uncloned = true
+		}
+	}
+	if !uncloned {
+		return nil
+	}
+
+	cloned, err := rebuildRepos(m.reposDir, m.providers, m.exec, m.log)
+	if err != nil {
+		return err
+	}
+
+	var stale []*git.Repo
+	for _, clone := range cloned {
+		if !wanted[clone.String()] && !m.summary.isSkipped(clone) {
+			stale = append(stale, clone)
+		}
+	}
+
+	metas := make([]*provider.Repository, len(stale))
+	for i, clone := range stale {
+		m.pool.Submit(func() {
+			// Clones of repositories which cannot be queried are reported when identifying
+			if meta, metaErr := clone.Provider().Repository(ctx, clone.FullName); metaErr == nil {
+				metas[i] = meta
+			}
+		})
+	}
+	m.pool.Wait()
+
+	for i, clone := range stale {
+		meta := metas[i]
+		if meta == nil || !renamed(clone, meta) || !wanted[meta.String()] {
+			// Not renamed, but no longer matched by the search, which is reported when identifying
+			continue
+		}
+		if err = m.followRename(ctx, clone, meta.FullName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveClone moves the clone at oldPath to the path of the repository and points its origin at the new name.
+// If the repository was already cloned under its new name, or the clone cannot be moved, it is removed instead,
+// and the repository is cloned again.
+func (m *Manager) moveClone(ctx context.Context, repo *git.Repo, oldPath string) error {
+	newPath := repo.LocalPath()
+	defer removeEmptyParents(filepath.Dir(oldPath), m.reposDir)
+
+	_, statErr := os.Stat(newPath)
+	if statErr == nil {
+		m.log.Info(fmt.Sprintf("Removing %s, which is already cloned at %s", oldPath, newPath))
+		return removeClone(oldPath)
+	}
+
+	moveErr := os.MkdirAll(filepath.Dir(newPath), DefaultFilePerms)
+	if moveErr == nil {
+		moveErr = os.Rename(oldPath, newPath)
+	}
+	if moveErr != nil {
+		m.log.Warn(fmt.Sprintf("Failed to move %s to %s, cloning it again: %v", oldPath, newPath, moveErr))
+		return removeClone(oldPath)
+	}
+
+	m.log.Info(fmt.Sprintf("Moved %s to %s", oldPath, newPath))
+	return repo.SetOrigin(ctx)
+}
+
+func removeClone(path string) error {
+	if err := os.RemoveAll(path); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	return nil
+}
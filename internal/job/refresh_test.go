@@ -0,0 +1,147 @@
+package job //nolint:testpackage // internal testing needed for unexported functions
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fredrikaverpil/multipr/internal/gittest"
+	"github.com/fredrikaverpil/multipr/internal/provider"
+	"github.com/fredrikaverpil/multipr/internal/provider/providertest"
+)
+
+func TestRunWorkflow_RenamedAndArchived(t *testing.T) {
+	remotes := gittest.New(t)
+	repos := map[string]string{}
+	for _, name := range []string{"acme/listed", "acme/searched", "acme/archived"} {
+		repos[name] = remotes.Create(name+".git", "main", map[string]string{"schedule.txt": "daily\n"})
+	}
+	memory := providertest.NewMemory("example.com", repos)
+
+	cfg := newTestJobConfig()
+	cfg.Search.Repos = []string{"example.com/acme/listed", "example.com/acme/searched", "example.com/acme/archived"}
+
+	m := newWorkflowManagerForTest(t, cfg, &CLIOptions{Shell: "sh", Workers: 2, Prune: true}, memory)
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	// A repository still listed under its old name, one found under its new name, and an archived one
+	memory.Rename("acme/listed", "platform/listed")
+	memory.Rename("acme/searched", "acme/found")
+	memory.SetMetadata(provider.Repository{FullName: "acme/archived", Archived: true})
+	cfg.Search.Repos = []string{"example.com/acme/listed", "example.com/acme/found", "example.com/acme/archived"}
+
+	m.options.Publish = true
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	clones := filepath.Join(m.reposDir, "example.com")
+	for _, moved := range []struct{ from, to string }{
+		{from: "acme/listed", to: "platform/listed"},
+		{from: "acme/searched", to: "acme/found"},
+	} {
+		if _, err := os.Stat(filepath.Join(clones, moved.from)); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be moved, got %v", moved.from, err)
+		}
+		// The moved clone is used as is, so its branch holds both runs' commits
+		if got := gittest.Run(t, filepath.Join(clones, moved.to), "rev-list", "--count", "HEAD"); got != "2" {
+			t.Fatalf("expected the clone of %s to be moved, got %s commits", moved.to, got)
+		}
+		if n := len(memory.PRs(moved.to)); n != 1 {
+			t.Fatalf("expected a PR for %s, got %d", moved.to, n)
+		}
+	}
+
+	// The clone of the archived repository is kept, but not pruned
+	if _, err := os.Stat(filepath.Join(clones, "acme/archived")); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(memory.PRs("acme/archived")); n != 0 {
+		t.Fatalf("expected no PR for an archived repository, got %d", n)
+	}
+	want := "  - acme/archived: skipped (repository is archived)"
+	if got := m.summary.lines(); !slices.Contains(got, want) {
+		t.Fatalf("expected the archived repository to be skipped, got:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestRunWorkflow_ReusesSearchMetadata(t *testing.T) {
+	remotes := gittest.New(t)
+	repos := map[string]string{}
+	for _, name := range []string{"acme/api", "acme/web"} {
+		repos[name] = remotes.Create(name+".git", "main", map[string]string{"schedule.txt": "daily\n"})
+	}
+	memory := providertest.NewMemory("example.com", repos)
+	for name := range repos {
+		memory.SetMetadata(provider.Repository{FullName: name, DefaultBranch: "main"})
+	}
+
+	cfg := newTestJobConfig()
+	cfg.Search.Repos = []string{"example.com/acme/api", "example.com/acme/web"}
+
+	m := newWorkflowManagerForTest(t, cfg, &CLIOptions{Shell: "sh", Workers: 2}, memory)
+	for _, skipSearch := range []bool{false, false, true} {
+		m.options.SkipSearch = skipSearch
+		if err := m.RunWorkflow(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := memory.Lookups(); n != 0 {
+		t.Fatalf("expected the metadata of the search to be reused, got %d lookups", n)
+	}
+}
+
+func TestRunWorkflow_StoresQueriedMetadata(t *testing.T) {
+	remotes := gittest.New(t)
+	repos := map[string]string{}
+	for _, name := range []string{"acme/api", "acme/web"} {
+		repos[name] = remotes.Create(name+".git", "main", map[string]string{"schedule.txt": "daily\n"})
+	}
+	memory := providertest.NewMemory("example.com", repos)
+
+	// Listed repositories have no metadata, so it is queried once, and then stored with the search
+	cfg := newTestJobConfig()
+	cfg.Search.Repos = []string{"example.com/acme/api", "example.com/acme/web"}
+
+	m := newWorkflowManagerForTest(t, cfg, &CLIOptions{Shell: "sh", Workers: 2}, memory)
+	for _, skipSearch := range []bool{false, true, true} {
+		m.options.SkipSearch = skipSearch
+		if err := m.RunWorkflow(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := memory.Lookups(); n != 2 {
+		t.Fatalf("expected the queried metadata to be reused with -skip-search, got %d lookups", n)
+	}
+}
+
+func TestRunWorkflow_ArchivedFilter(t *testing.T) {
+	remotes := gittest.New(t)
+	bare := remotes.Create("acme/archived.git", "main", map[string]string{"schedule.txt": "daily\n"})
+	memory := providertest.NewMemory("example.com", map[string]string{"acme/archived": bare})
+	memory.SetMetadata(provider.Repository{FullName: "acme/archived", Archived: true})
+
+	// Archived repositories are not skipped when the search asks for them
+	archived := true
+	cfg := newTestJobConfig()
+	cfg.Search.Repos = []string{"example.com/acme/archived"}
+	cfg.Search.Filters.Archived = &archived
+
+	m := newWorkflowManagerForTest(t, cfg, &CLIOptions{Shell: "sh", Workers: 2}, memory)
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(m.reposDir, "example.com", "acme/archived")); err != nil {
+		t.Fatalf("expected the archived repository to be cloned: %v", err)
+	}
+	if got := m.summary.lines(); slices.Contains(got, "  - acme/archived: skipped (repository is archived)") {
+		t.Fatalf("expected the archived repository not to be skipped, got:\n%s", strings.Join(got, "\n"))
+	}
+}
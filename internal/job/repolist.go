@@ -0,0 +1,96 @@
+package job
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fredrikaverpil/multipr/internal/config"
+	"github.com/fredrikaverpil/multipr/internal/provider"
+)
+
+// staticRepositories returns the repositories listed in repos and repos_file of the search source.
+func (m *Manager) staticRepositories(source config.SearchSource) ([]provider.Repository, error) {
+	refs := append([]string{}, source.Repos...)
+
+	if source.ReposFile != "" {
+		path := source.ReposFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(m.jobFilePath), path)
+		}
+
+		f, err := os.Open(path) // #nosec G304 -- path is trusted input
+		if err != nil {
+			return nil, fmt.Errorf("failed to open repos file: %w", err)
+		}
+		defer f.Close()
+
+		fileRefs, err := parseRepoList(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse repos file %s: %w", path, err)
+		}
+		refs = append(refs, fileRefs...)
+	}
+
+	var repos []provider.Repository
+	for _, ref := range refs {
+		host, fullName, err := splitRepoRef(ref)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := m.providers.Get(host); !ok {
+			return nil, fmt.Errorf("no provider configured for host %s (in %s)", host, ref)
+		}
+		repos = append(repos, provider.Repository{Host: host, FullName: fullName})
+	}
+
+	return repos, nil
+}
+
+// parseRepoList reads repository references from a newline separated or CSV file.
+// Only the first column is used, empty lines and lines starting with # are ignored,
+// and a leading header row (without any "/") is skipped.
+func parseRepoList(r io.Reader) ([]string, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var refs []string
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		ref := strings.TrimSpace(record[0])
+		if ref == "" || (first && !strings.Contains(ref, "/")) {
+			continue
+		}
+		refs = append(refs, ref)
+	}
+
+	return refs, nil
+}
+
+// splitRepoRef splits a reference like "github.com/owner/repo" into host and full name.
+// An optional https:// scheme and .git suffix are accepted, so clone URLs can be pasted as-is.
+func splitRepoRef(ref string) (string, string, error) {
+	trimmed := strings.TrimPrefix(ref, "https://")
+	trimmed = strings.TrimSuffix(strings.TrimSuffix(trimmed, "/"), ".git")
+
+	host, fullName, ok := strings.Cut(trimmed, "/")
+	if !ok || host == "" || !strings.Contains(fullName, "/") || strings.HasSuffix(fullName, "/") {
+		return "", "", fmt.Errorf("invalid repository %q, expected host/owner/name", ref)
+	}
+
+	return host, fullName, nil
+}
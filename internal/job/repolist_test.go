@@ -0,0 +1,61 @@
+package job //nolint:testpackage // internal testing needed for unexported functions
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseRepoList_Newlines(t *testing.T) {
+	input := "# campaign targets\ngithub.com/acme/api\n\n  gitlab.example.com/platform/infra/terraform\n"
+	got, err := parseRepoList(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"github.com/acme/api", "gitlab.example.com/platform/infra/terraform"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseRepoList_CSVWithHeader(t *testing.T) {
+	input := "repository,owner team,notes\ngithub.com/acme/api,backend,\"needs review, soon\"\ngithub.com/acme/web,frontend,\n"
+	got, err := parseRepoList(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"github.com/acme/api", "github.com/acme/web"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestSplitRepoRef(t *testing.T) {
+	tests := []struct {
+		ref      string
+		host     string
+		fullName string
+		wantErr  bool
+	}{
+		{ref: "github.com/acme/api", host: "github.com", fullName: "acme/api"},
+		{ref: "https://github.com/acme/api.git", host: "github.com", fullName: "acme/api"},
+		{ref: "gitlab.com/group/sub/project", host: "gitlab.com", fullName: "group/sub/project"},
+		{ref: "acme/api", wantErr: true},
+		{ref: "github.com/acme/", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		host, fullName, err := splitRepoRef(tt.ref)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected error", tt.ref)
+			}
+			continue
+		}
+		if err != nil || host != tt.host || fullName != tt.fullName {
+			t.Errorf("%s: got (%q, %q, %v), want (%q, %q)", tt.ref, host, fullName, err, tt.host, tt.fullName)
+		}
+	}
+}
@@ -2,54 +2,182 @@ package job
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 
+	"github.com/fredrikaverpil/multipr/internal/config"
 	"github.com/fredrikaverpil/multipr/internal/git"
+	"github.com/fredrikaverpil/multipr/internal/provider"
 )
 
-func (m *Manager) searchRepositories(ctx context.Context) ([]*git.Repo, error) {
+func (m *Manager) searchRepositories(ctx context.Context) ([]provider.Repository, error) {
 	if err := os.MkdirAll(m.reposDir, DefaultFilePerms); err != nil {
 		return nil, fmt.Errorf("failed to create repositories directory: %w", err)
 	}
 
-	// Check if GitHub search is configured
-	if m.config.Search.GitHub.Method != "" {
-		method := m.config.Search.GitHub.Method
-		query := m.config.Search.GitHub.Query
+	results, err := m.collectSearchResults(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-		m.log.Info(fmt.Sprintf("Searching GitHub using method '%s' with query: %s", method, query))
+	return m.filterRepositories(ctx, results)
+}
 
-		var fullNames []string
-		var err error
+// newRepos converts search results into repos, using the provider of each host.
+func (m *Manager) newRepos(results []provider.Repository) ([]*git.Repo, error) {
+	repos := []*git.Repo{}
+	for _, result := range results {
+		p, ok := m.providers.Get(result.Host)
+		if !ok {
+			return nil, fmt.Errorf("no provider configured for host %s", result.Host)
+		}
+		repo := git.NewRepo(p, result.FullName, m.reposDir, m.exec, m.log)
+		m.configureRepo(repo)
+		if result.HasMetadata {
+			repo.Metadata = &result
+		}
+		repos = append(repos, repo)
+	}
+	return repos, nil
+}
 
-		switch method {
-		case "code":
-			fullNames, err = m.exec.GHSearchCode(ctx, query, 0)
-		case "repos":
-			fullNames, err = m.exec.GHSearchRepos(ctx, query, 0)
-		default:
-			return nil, fmt.Errorf("unsupported GitHub search method: %s", method)
+// collectSearchResults runs each search source and combines their results in order.
+func (m *Manager) collectSearchResults(ctx context.Context) ([]provider.Repository, error) {
+	var results []provider.Repository
+
+	for i, source := range m.config.Search.All() {
+		name := source.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
 		}
 
+		combine := source.Combine
+		if combine == "" {
+			combine = config.CombineUnion
+		}
+		if i == 0 && combine != config.CombineUnion {
+			return nil, fmt.Errorf("search source %s: the first source cannot be combined with '%s'", name, combine)
+		}
+
+		found, err := m.searchSource(ctx, source)
 		if err != nil {
-			return nil, fmt.Errorf("failed GitHub search: %w", err)
+			return nil, fmt.Errorf("search source %s: %w", name, err)
 		}
 
-		m.log.Info(fmt.Sprintf("Found %d repositories", len(fullNames)))
-		for _, fullName := range fullNames {
-			m.log.Info(fmt.Sprintf("  - github.com/%s", fullName))
+		before := len(results)
+		results, err = combineResults(combine, results, found)
+		if err != nil {
+			return nil, fmt.Errorf("search source %s: %w", name, err)
 		}
 
-		// Convert to repos
-		var repos []*git.Repo
-		for _, fullName := range fullNames {
-			newRepo := git.NewRepo("github.com", fullName, m.reposDir, m.exec, m.log)
-			repos = append(repos, newRepo)
+		m.log.Info(fmt.Sprintf(
+			"Search source %s matched %d repositories (%s: %d -> %d)",
+			name, len(found), combine, before, len(results),
+		))
+	}
+
+	return results, nil
+}
+
+// searchSource runs the search of each provider and adds the listed repositories,
+// de-duplicated by host and full name.
+func (m *Manager) searchSource(ctx context.Context, source config.SearchSource) ([]provider.Repository, error) {
+	if source.Gitea.Enabled() && source.Gitea.Host == "" {
+		return nil, errors.New("a Gitea search requires a host")
+	}
+	if source.Bitbucket.Enabled() && source.Bitbucket.Host == "" {
+		return nil, errors.New("a Bitbucket search requires a host")
+	}
+	if source.AzureDevOps.Enabled() && source.AzureDevOps.Organization == "" {
+		return nil, errors.New("an Azure DevOps search requires an organization")
+	}
+
+	var results []provider.Repository
+
+	// Providers may apply the filters themselves (e.g. GitLab leaves out archived projects)
+	opts := provider.SearchOptions{Archived: m.config.Search.Filters.Archived}
+
+	// Each provider searches with its own part of the search configuration, if any
+	for _, p := range m.providers.All() {
+		found, err := p.Search(ctx, source, opts)
+		if err != nil {
+			return nil, err
 		}
+		if len(found) == 0 {
+			continue
+		}
+
+		m.log.Info(fmt.Sprintf("Found %d repositories on %s", len(found), p.Host()))
+		for _, repo := range found {
+			m.log.Info(fmt.Sprintf("  - %s", repo.String()))
+		}
+		results = append(results, found...)
+	}
 
-		return repos, nil
+	// Repositories listed explicitly in the job are added to the search results
+	listed, err := m.staticRepositories(source)
+	if err != nil {
+		return nil, err
+	}
+	if len(listed) > 0 {
+		m.log.Info(fmt.Sprintf("Found %d listed repositories", len(listed)))
+		for _, repo := range listed {
+			m.log.Info(fmt.Sprintf("  - %s", repo.String()))
+		}
+		results = append(results, listed...)
+	}
+
+	return combineResults(config.CombineUnion, nil, results)
+}
+
+// combineResults combines the accumulated results with the results of a source,
+// de-duplicated by host and full name. The order of the accumulated results is kept,
+// and a repository with metadata is preferred over the same repository without.
+func combineResults(combine string, acc, found []provider.Repository) ([]provider.Repository, error) {
+	foundByName := make(map[string]provider.Repository, len(found))
+	for _, repo := range found {
+		if existing, ok := foundByName[repo.String()]; !ok || !existing.HasMetadata {
+			foundByName[repo.String()] = repo
+		}
+	}
+
+	var results []provider.Repository
+	seen := make(map[string]struct{})
+	keep := func(repo provider.Repository) {
+		if _, ok := seen[repo.String()]; ok {
+			return
+		}
+		if other, ok := foundByName[repo.String()]; ok && !repo.HasMetadata && other.HasMetadata {
+			repo = other
+		}
+		seen[repo.String()] = struct{}{}
+		results = append(results, repo)
+	}
+
+	switch combine {
+	case config.CombineUnion:
+		for _, repo := range acc {
+			keep(repo)
+		}
+		for _, repo := range found {
+			keep(repo)
+		}
+	case config.CombineIntersect:
+		for _, repo := range acc {
+			if _, ok := foundByName[repo.String()]; ok {
+				keep(repo)
+			}
+		}
+	case config.CombineSubtract:
+		for _, repo := range acc {
+			if _, ok := foundByName[repo.String()]; !ok {
+				keep(repo)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported combine '%s', expected union, intersect or subtract", combine)
 	}
 
-	return []*git.Repo{}, nil
+	return results, nil
 }
@@ -0,0 +1,68 @@
+package job //nolint:testpackage // internal testing needed for unexported functions
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fredrikaverpil/multipr/internal/config"
+	"github.com/fredrikaverpil/multipr/internal/provider"
+)
+
+func testRepos(names ...string) []provider.Repository {
+	repos := make([]provider.Repository, 0, len(names))
+	for _, name := range names {
+		repos = append(repos, provider.Repository{Host: "github.com", FullName: name})
+	}
+	return repos
+}
+
+func repoNames(repos []provider.Repository) string {
+	names := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		names = append(names, repo.FullName)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestCombineResults(t *testing.T) {
+	acc := testRepos("acme/a", "acme/b", "acme/c")
+	found := testRepos("acme/c", "acme/d", "acme/b", "acme/d")
+
+	tests := []struct {
+		combine string
+		want    string
+	}{
+		{combine: config.CombineUnion, want: "acme/a,acme/b,acme/c,acme/d"},
+		{combine: config.CombineIntersect, want: "acme/b,acme/c"},
+		{combine: config.CombineSubtract, want: "acme/a"},
+	}
+
+	for _, tt := range tests {
+		got, err := combineResults(tt.combine, acc, found)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if repoNames(got) != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.combine, repoNames(got), tt.want)
+		}
+	}
+
+	if _, err := combineResults("xor", acc, found); err == nil {
+		t.Error("expected error for unsupported combine")
+	}
+}
+
+func TestCombineResults_PrefersMetadata(t *testing.T) {
+	acc := testRepos("acme/a")
+	found := testRepos("acme/a")
+	found[0].HasMetadata = true
+	found[0].Archived = true
+
+	got, err := combineResults(config.CombineIntersect, acc, found)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || !got[0].HasMetadata || !got[0].Archived {
+		t.Fatalf("expected repository with metadata, got %+v", got)
+	}
+}
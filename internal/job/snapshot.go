@@ -0,0 +1,162 @@
+package job
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/fredrikaverpil/multipr/internal/git"
+	"github.com/fredrikaverpil/multipr/internal/log"
+	"github.com/fredrikaverpil/multipr/internal/provider"
+)
+
+const searchSnapshotFile = "search.json"
+
+// searchSnapshot is the persisted result of a search, stored in the job work dir.
+type searchSnapshot struct {
+	Time         time.Time             `json:"time"`
+	Query        any                   `json:"query"` // the search block of the job
+	Repositories []provider.Repository `json:"repositories"`
+}
+
+func (m *Manager) searchSnapshotPath() string {
+	return filepath.Join(m.workDir, searchSnapshotFile)
+}
+
+// loadSearchSnapshot loads the previous search result, or returns nil if there is none.
+func (m *Manager) loadSearchSnapshot() (*searchSnapshot, error) {
+	data, err := os.ReadFile(m.searchSnapshotPath())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil //nolint:nilnil // no previous search is not an error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read search snapshot: %w", err)
+	}
+
+	var snapshot searchSnapshot
+	if err = json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse search snapshot %s: %w", m.searchSnapshotPath(), err)
+	}
+	return &snapshot, nil
+}
+
+// saveSearchSnapshot persists the search result.
+func (m *Manager) saveSearchSnapshot(repos []provider.Repository) error {
+	// Round-trip the search block through YAML, so it is stored with the keys used in the job file
+	var query any
+	data, err := yaml.Marshal(m.config.Search)
+	if err == nil {
+		err = yaml.Unmarshal(data, &query)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode search query: %w", err)
+	}
+
+	snapshot := searchSnapshot{
+		Time:         time.Now().UTC(),
+		Query:        query,
+		Repositories: repos,
+	}
+	if snapshot.Repositories == nil {
+		snapshot.Repositories = []provider.Repository{}
+	}
+	return m.writeSearchSnapshot(&snapshot)
+}
+
+// storeSearchMetadata adds the queried metadata of the repositories to the search snapshot, so that runs
+// with -skip-search use it instead of querying it again. Renamed repositories are left as they were found.
+func (m *Manager) storeSearchMetadata(repos []*git.Repo, metas []*provider.Repository) error {
+	queried := make(map[string]provider.Repository)
+	for i, repo := range repos {
+		if repo.Metadata == nil && metas[i] != nil && !renamed(repo, metas[i]) {
+			queried[repo.String()] = *metas[i]
+		}
+	}
+	if len(queried) == 0 {
+		return nil
+	}
+
+	snapshot, err := m.loadSearchSnapshot()
+	if err != nil || snapshot == nil {
+		return err
+	}
+	for i, repo := range snapshot.Repositories {
+		if meta, ok := queried[repo.String()]; ok {
+			snapshot.Repositories[i] = meta
+		}
+	}
+	return m.writeSearchSnapshot(snapshot)
+}
+
+func (m *Manager) writeSearchSnapshot(snapshot *searchSnapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode search snapshot: %w", err)
+	}
+
+	if err = os.MkdirAll(m.workDir, DefaultFilePerms); err != nil {
+		return fmt.Errorf("failed to create job directory: %w", err)
+	}
+	if err = os.WriteFile(m.searchSnapshotPath(), data, log.RegularFilePerms); err != nil {
+		return fmt.Errorf("failed to write search snapshot: %w", err)
+	}
+	return nil
+}
+
+// diffSearchResults returns the repositories which were added and removed since the previous result.
+func diffSearchResults(previous, current []provider.Repository) ([]provider.Repository, []provider.Repository) {
+	previousNames := make(map[string]struct{}, len(previous))
+	for _, repo := range previous {
+		previousNames[repo.String()] = struct{}{}
+	}
+	currentNames := make(map[string]struct{}, len(current))
+	for _, repo := range current {
+		currentNames[repo.String()] = struct{}{}
+	}
+
+	var added, removed []provider.Repository
+	for _, repo := range current {
+		if _, ok := previousNames[repo.String()]; !ok {
+			added = append(added, repo)
+		}
+	}
+	for _, repo := range previous {
+		if _, ok := currentNames[repo.String()]; !ok {
+			removed = append(removed, repo)
+		}
+	}
+	return added, removed
+}
+
+// logSearchChanges reports the changes of the search result since the previous snapshot.
+func (m *Manager) logSearchChanges(previous *searchSnapshot, current []provider.Repository) {
+	if previous == nil {
+		return
+	}
+
+	added, removed := diffSearchResults(previous.Repositories, current)
+	since := previous.Time.Local().Format(time.DateTime)
+	if len(added) == 0 && len(removed) == 0 {
+		m.log.Info(fmt.Sprintf("Search result is unchanged since the previous search (%s)", since))
+		return
+	}
+
+	if len(added) > 0 {
+		m.log.Info(fmt.Sprintf("%d repositories newly matched since the previous search (%s)", len(added), since))
+		for _, repo := range added {
+			m.log.Info(fmt.Sprintf("  + %s", repo.String()))
+		}
+	}
+	if len(removed) > 0 {
+		m.log.Info(fmt.Sprintf("%d repositories no longer matched since the previous search (%s)", len(removed), since))
+		for _, repo := range removed {
+			m.log.Info(fmt.Sprintf("  - %s", repo.String()))
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package job //nolint:testpackage // internal testing needed for unexported functions
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/fredrikaverpil/multipr/internal/provider"
+)
+
+func TestDiffSearchResults(t *testing.T) {
+	previous := testRepos("acme/a", "acme/b", "acme/c")
+	current := testRepos("acme/b", "acme/c", "acme/d")
+
+	added, removed := diffSearchResults(previous, current)
+
+	if got := repoNames(added); got != "acme/d" {
+		t.Fatalf("unexpected added repositories: %v", got)
+	}
+	if got := repoNames(removed); got != "acme/a" {
+		t.Fatalf("unexpected removed repositories: %v", got)
+	}
+}
+
+func TestSearchSnapshot_SaveAndLoad(t *testing.T) {
+	cfg := newTestJobConfig()
+	cfg.Search.Repos = []string{"github.com/acme/a"}
+	m := newWorkflowManagerForTest(t, cfg, &CLIOptions{Workers: 1})
+
+	snapshot, err := m.loadSearchSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot != nil {
+		t.Fatalf("expected no snapshot before the first search, got %+v", snapshot)
+	}
+
+	repos := testRepos("acme/a")
+	repos[0].HasMetadata = true
+	repos[0].Topics = []string{"go"}
+	if err = m.saveSearchSnapshot(repos); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err = m.loadSearchSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Time.IsZero() {
+		t.Fatal("expected the snapshot time to be set")
+	}
+	want := provider.Repository{Host: "github.com", FullName: "acme/a", HasMetadata: true, Topics: []string{"go"}}
+	if len(snapshot.Repositories) != 1 || snapshot.Repositories[0].String() != want.String() ||
+		!slices.Equal(snapshot.Repositories[0].Topics, want.Topics) {
+		t.Fatalf("unexpected repositories: %+v", snapshot.Repositories)
+	}
+	query, ok := snapshot.Query.(map[string]any)
+	if !ok || query["repos"] == nil {
+		t.Fatalf("expected the search query to be stored, got %#v", snapshot.Query)
+	}
+}
@@ -0,0 +1,110 @@
+package job
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/fredrikaverpil/multipr/internal/git"
+)
+
+// Outcomes of a repository in the run summary.
+const (
+	outcomeFailed      = "failed"
+	outcomeNotEligible = "not eligible"
+	outcomeSkipped     = "skipped"
+	outcomeChanged     = "changed"
+	outcomePRCreated   = "PR created"
+	outcomePRUpdated   = "PR updated"
+	outcomePushed      = "pushed"
+	outcomeUploaded    = "change uploaded"
+	outcomePatched     = "patches written"
+	outcomeMailed      = "patches sent"
+)
+
+// repoOutcome is the latest outcome of a repository during a run, with an optional detail such as a URL.
+type repoOutcome struct {
+	outcome string
+	detail  string
+}
+
+// runSummary collects the outcome of each repository during a run, which is reported grouped by host.
+// The zero value is ready to use.
+type runSummary struct {
+	mu    sync.Mutex
+	hosts map[string]map[string]repoOutcome // host -> full name -> outcome
+}
+
+// record sets the outcome of the repository, replacing the outcome of a previous step.
+func (s *runSummary) record(repo *git.Repo, outcome, detail string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.hosts == nil {
+		s.hosts = make(map[string]map[string]repoOutcome)
+	}
+	if s.hosts[repo.Host] == nil {
+		s.hosts[repo.Host] = make(map[string]repoOutcome)
+	}
+	s.hosts[repo.Host][repo.FullName] = repoOutcome{outcome: outcome, detail: detail}
+}
+
+// isSkipped reports whether the repository was skipped, e.g. because it is archived.
+func (s *runSummary) isSkipped(repo *git.Repo) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hosts[repo.Host][repo.FullName].outcome == outcomeSkipped
+}
+
+// reset forgets the outcomes of a previous run.
+func (s *runSummary) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hosts = nil
+}
+
+// lines returns the summary, with a line counting the outcomes of each host followed by a line per repository.
+func (s *runSummary) lines() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var lines []string
+	for _, host := range slices.Sorted(maps.Keys(s.hosts)) {
+		repos := s.hosts[host]
+
+		counts := make(map[string]int)
+		for _, o := range repos {
+			counts[o.outcome]++
+		}
+		var parts []string
+		for _, outcome := range slices.Sorted(maps.Keys(counts)) {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[outcome], outcome))
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", host, strings.Join(parts, ", ")))
+
+		for _, fullName := range slices.Sorted(maps.Keys(repos)) {
+			o := repos[fullName]
+			line := fmt.Sprintf("  - %s: %s", fullName, o.outcome)
+			if o.detail != "" {
+				line += " (" + o.detail + ")"
+			}
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// logSummary reports the outcome of the repositories of the run, grouped by host.
+func (m *Manager) logSummary() {
+	lines := m.summary.lines()
+	if len(lines) == 0 {
+		return
+	}
+
+	m.log.Info("Summary:")
+	for _, line := range lines {
+		m.log.Info(line)
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/fredrikaverpil/multipr/internal/config"
 	"github.com/fredrikaverpil/multipr/internal/git"
 )
 
@@ -14,6 +16,10 @@ import (
 func (m *Manager) RunWorkflow(ctx context.Context) error {
 	m.logJobStart()
 
+	// The summary is also reported when a step fails
+	m.summary.reset()
+	defer m.logSummary()
+
 	if err := m.handleCleanup(); err != nil {
 		return err
 	}
@@ -24,12 +30,12 @@ func (m *Manager) RunWorkflow(ctx context.Context) error {
 	}
 
 	if len(repos) > 0 {
-		if err = m.handleRepositoryCloning(ctx, repos); err != nil {
+		if repos, err = m.handleRepositoryCloning(ctx, repos); err != nil {
 			return err
 		}
 	}
 
-	eligibleRepos, err := m.handleEligibleRepoIdentification(ctx)
+	eligibleRepos, err := m.handleEligibleRepoIdentification(ctx, repos)
 	if err != nil {
 		return err
 	}
@@ -75,7 +81,28 @@ func (m *Manager) handleCleanup() error {
 }
 
 func (m *Manager) handleRepositorySearch(ctx context.Context) ([]*git.Repo, error) {
+	previous, err := m.loadSearchSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
 	if m.options.SkipSearch {
+		if previous == nil {
+			return nil, nil
+		}
+		m.log.Info(fmt.Sprintf(
+			"Skipping search, using %d repositories from the previous search (%s)",
+			len(previous.Repositories), previous.Time.Local().Format(time.DateTime),
+		))
+		return m.newRepos(previous.Repositories)
+	}
+
+	// Without a search, all existing clones are used, so there is nothing to reconcile them with
+	if len(m.config.Search.All()) == 0 {
+		m.log.Info("No search configured, using the existing clones")
+		if err = os.MkdirAll(m.reposDir, DefaultFilePerms); err != nil {
+			return nil, fmt.Errorf("failed to create repositories directory: %w", err)
+		}
 		return nil, nil
 	}
 
@@ -83,31 +110,45 @@ func (m *Manager) handleRepositorySearch(ctx context.Context) ([]*git.Repo, erro
 		return nil, nil
 	}
 
-	repos, err := m.searchRepositories(ctx)
+	results, err := m.searchRepositories(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("error searching repositories: %w", err)
 	}
-	return repos, nil
+
+	m.logSearchChanges(previous, results)
+	if err = m.saveSearchSnapshot(results); err != nil {
+		return nil, err
+	}
+
+	return m.newRepos(results)
 }
 
-func (m *Manager) handleRepositoryCloning(ctx context.Context, repos []*git.Repo) error {
+// handleRepositoryCloning clones the repositories, or updates existing clones, and returns the repositories
+// to continue with: renamed repositories under their new name, and without skipped (e.g. archived) ones.
+func (m *Manager) handleRepositoryCloning(ctx context.Context, repos []*git.Repo) ([]*git.Repo, error) {
 	if m.options.ReviewSteps && !m.confirmStep(fmt.Sprintf("Clone down repositories to local disk? [%s]", m.reposDir)) {
-		return nil
+		return repos, nil
 	}
 
-	_, err := m.cloneRepositories(ctx, repos)
+	repos, err := m.refreshRepositories(ctx, repos)
 	if err != nil {
-		return fmt.Errorf("error cloning repositories: %w", err)
+		return nil, fmt.Errorf("error refreshing repositories: %w", err)
 	}
-	return nil
+
+	if _, err = m.cloneRepositories(ctx, repos); err != nil {
+		return nil, fmt.Errorf("error cloning repositories: %w", err)
+	}
+	return repos, nil
 }
 
-func (m *Manager) handleEligibleRepoIdentification(ctx context.Context) ([]*git.Repo, error) {
+// handleEligibleRepoIdentification identifies eligible repositories among the clones.
+// When searched is not nil, clones which are not part of the search result are skipped.
+func (m *Manager) handleEligibleRepoIdentification(ctx context.Context, searched []*git.Repo) ([]*git.Repo, error) {
 	if m.options.ReviewSteps && !m.confirmStep("Identify eligible repositories?") {
 		return nil, nil
 	}
 
-	eligibleRepos, err := m.identifyEligibleRepos(ctx, m.reposDir)
+	eligibleRepos, err := m.identifyEligibleRepos(ctx, m.reposDir, searched)
 	if err != nil {
 		return nil, fmt.Errorf("error identifying eligible repositories: %w", err)
 	}
@@ -139,9 +180,16 @@ func (m *Manager) handlePublishing(ctx context.Context, processedRepos []*git.Re
 }
 
 func (m *Manager) logJobCompletion() {
-	if !m.options.Publish {
+	switch {
+	case !m.options.Publish:
 		m.log.Info("☂️ To publish PRs, use the -publish flag")
-	} else {
+	case m.publishMode() == config.PublishModePush:
+		m.log.Info("🚀 Branches pushed successfully!")
+	case m.publishMode() == config.PublishModeGerrit:
+		m.log.Info("🚀 Changes uploaded successfully!")
+	case m.publishMode() == config.PublishModeEmail:
+		m.log.Info("🚀 Patch series published successfully!")
+	default:
 		m.log.Info("🚀 PRs published successfully!")
 	}
 }
@@ -0,0 +1,433 @@
+package job //nolint:testpackage // internal testing needed for unexported fields
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fredrikaverpil/multipr/internal/azuredevops"
+	"github.com/fredrikaverpil/multipr/internal/azuredevops/azuredevopstest"
+	"github.com/fredrikaverpil/multipr/internal/bitbucket"
+	"github.com/fredrikaverpil/multipr/internal/bitbucket/bitbuckettest"
+	"github.com/fredrikaverpil/multipr/internal/command"
+	"github.com/fredrikaverpil/multipr/internal/config"
+	"github.com/fredrikaverpil/multipr/internal/gitea"
+	"github.com/fredrikaverpil/multipr/internal/gitea/giteatest"
+	"github.com/fredrikaverpil/multipr/internal/github"
+	"github.com/fredrikaverpil/multipr/internal/github/githubtest"
+	"github.com/fredrikaverpil/multipr/internal/gittest"
+	"github.com/fredrikaverpil/multipr/internal/log"
+	"github.com/fredrikaverpil/multipr/internal/provider"
+	"github.com/fredrikaverpil/multipr/internal/provider/providertest"
+	"github.com/fredrikaverpil/multipr/internal/worker"
+)
+
+const testBranch = "multipr/test"
+
+// newWorkflowManagerForTest creates a manager running the full workflow against the given providers.
+
+func newWorkflowManagerForTest(
+	t *testing.T,
+	cfg *config.JobConfig,
+	opts *CLIOptions,
+	providers ...provider.Provider,
+) *Manager {
+	t.Helper()
+
+	// The changes are committed as the test user
+	t.Setenv("GIT_AUTHOR_NAME", "multipr")
+	t.Setenv("GIT_AUTHOR_EMAIL", "multipr@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "multipr")
+	t.Setenv("GIT_COMMITTER_EMAIL", "multipr@example.com")
+
+	logger, err := log.NewLogger(log.Options{LevelDebug: false})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	workDir := t.TempDir()
+	jobPath := filepath.Join(workDir, "job.yml")
+	if err = os.WriteFile(jobPath, []byte("name: test\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return &Manager{
+		config:      cfg,
+		options:     opts,
+		workDir:     workDir,
+		reposDir:    filepath.Join(workDir, "repos"),
+		jobFilePath: jobPath,
+		log:         logger,
+		exec:        command.NewExecutor(false, "sh", logger),
+		providers:   provider.NewRegistry(providers...),
+		pool:        worker.NewWorkerPool(opts.Workers),
+	}
+}
+
+func newTestJobConfig() *config.JobConfig {
+	cfg := &config.JobConfig{Name: "test"}
+	cfg.Identify = []config.Command{{Name: "find daily", Cmd: "grep -q daily schedule.txt"}}
+	cfg.Changes = []config.Command{{Name: "use weekly", Cmd: "printf 'weekly\\n' > schedule.txt"}}
+	cfg.PR.GitHub = config.PullRequest{Title: "chore: weekly schedule", Body: "body", Branch: testBranch}
+	return cfg
+}
+
+func TestRunWorkflow_MemoryProvider(t *testing.T) {
+	remotes := gittest.New(t)
+	memory := providertest.NewMemory("example.com", map[string]string{
+		"acme/daily":  remotes.Create("acme/daily.git", "main", map[string]string{"schedule.txt": "daily\n"}),
+		"acme/weekly": remotes.Create("acme/weekly.git", "main", map[string]string{"schedule.txt": "weekly\n"}),
+	})
+
+	cfg := newTestJobConfig()
+	cfg.Search.Repos = []string{"example.com/acme/daily", "example.com/acme/weekly"}
+
+	opts := &CLIOptions{Publish: true, Shell: "sh", Workers: 2}
+	m := newWorkflowManagerForTest(t, cfg, opts, memory)
+
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	prs := memory.PRs("acme/daily")
+	if len(prs) != 1 {
+		t.Fatalf("expected 1 PR for acme/daily, got %d", len(prs))
+	}
+	if prs[0].Branch != testBranch || prs[0].Title != "chore: weekly schedule" || prs[0].Draft {
+		t.Fatalf("unexpected PR: %+v", prs[0])
+	}
+	if n := len(memory.PRs("acme/weekly")); n != 0 {
+		t.Fatalf("expected no PR for ineligible acme/weekly, got %d", n)
+	}
+
+	// The branch was pushed to the remote
+	if got := remotes.File("acme/daily.git", testBranch, "schedule.txt"); got != "weekly" {
+		t.Fatalf("expected pushed branch to contain the change, got %q", got)
+	}
+
+	// Re-running as draft edits the existing PR instead of creating a new one
+	opts.Draft = true
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	prs = memory.PRs("acme/daily")
+	if len(prs) != 1 || !prs[0].Draft {
+		t.Fatalf("expected the single PR to become a draft, got %+v", prs)
+	}
+}
+
+func TestRunWorkflow_MultipleHosts(t *testing.T) {
+	remotes := gittest.New(t)
+	public := providertest.NewMemory("git.example.com", map[string]string{
+		"acme/daily":  remotes.Create("acme/daily.git", "main", map[string]string{"schedule.txt": "daily\n"}),
+		"acme/weekly": remotes.Create("acme/weekly.git", "main", map[string]string{"schedule.txt": "weekly\n"}),
+	})
+	// The repositories of the second host can only be reached with the environment of its hosts entry
+	internal := providertest.NewMemory("git.internal.example.com", map[string]string{
+		"platform/daily": "internal:platform/daily.git",
+	})
+	remotes.Create("platform/daily.git", "main", map[string]string{"schedule.txt": "daily\n"})
+
+	cfg := newTestJobConfig()
+	cfg.Search.Repos = []string{
+		"git.example.com/acme/daily",
+		"git.example.com/acme/weekly",
+		"git.internal.example.com/platform/daily",
+	}
+	cfg.Hosts = []config.Host{{
+		Host: "git.internal.example.com",
+		Env: map[string]string{
+			"GIT_CONFIG_COUNT":   "1",
+			"GIT_CONFIG_KEY_0":   "url." + remotes.Dir() + "/.insteadOf",
+			"GIT_CONFIG_VALUE_0": "internal:",
+		},
+		PR: &config.PullRequest{Title: "chore: weekly schedule [internal]", Body: "body", Branch: testBranch},
+	}}
+
+	m := newWorkflowManagerForTest(t, cfg, &CLIOptions{Publish: true, Shell: "sh", Workers: 2}, public, internal)
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := remotes.File("platform/daily.git", testBranch, "schedule.txt"); got != "weekly" {
+		t.Fatalf("expected pushed branch to contain the change, got %q", got)
+	}
+	// The PR of the second host is overridden by its hosts entry
+	for title, prs := range map[string][]providertest.MemoryPR{
+		"chore: weekly schedule":            public.PRs("acme/daily"),
+		"chore: weekly schedule [internal]": internal.PRs("platform/daily"),
+	} {
+		if len(prs) != 1 || prs[0].Title != title {
+			t.Fatalf("expected a PR titled %q, got %+v", title, prs)
+		}
+	}
+
+	want := []string{
+		"git.example.com: 1 PR created, 1 not eligible",
+		"  - acme/daily: PR created (memory://git.example.com/acme/daily/pull/1)",
+		"  - acme/weekly: not eligible",
+		"git.internal.example.com: 1 PR created",
+		"  - platform/daily: PR created (memory://git.internal.example.com/platform/daily/pull/1)",
+	}
+	if got := m.summary.lines(); !slices.Equal(got, want) {
+		t.Fatalf("unexpected summary:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestRunWorkflow_GitHub(t *testing.T) {
+	// The server rejects git requests without the token, which must not fall back to a prompt
+	t.Setenv("GIT_TERMINAL_PROMPT", "0")
+
+	server := githubtest.NewServer(t)
+	server.AddRepository(github.Repository{FullName: "acme/daily"}, map[string]string{"schedule.txt": "daily\n"})
+	server.AddRepository(github.Repository{FullName: "acme/weekly"}, map[string]string{"schedule.txt": "weekly\n"})
+	server.AddRepository(github.Repository{FullName: "other/daily"}, map[string]string{"schedule.txt": "daily\n"})
+
+	cfg := newTestJobConfig()
+	cfg.Search.GitHub = config.GitHubSearch{
+		Host:   "github.example.com",
+		Method: "code",
+		Query:  "--owner acme --filename schedule.txt",
+	}
+
+	opts := &CLIOptions{Publish: true, Shell: "sh", Workers: 2}
+	m := newWorkflowManagerForTest(t, cfg, opts)
+	client := github.NewClient(server.URL, githubtest.Token, nil)
+	m.providers.Register(provider.NewGitHub("github.example.com", client, func() string { return provider.GitProtocolHTTPS }, m.log))
+
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	prs := server.PullRequests("acme/daily")
+	if len(prs) != 1 {
+		t.Fatalf("expected 1 PR for acme/daily, got %d", len(prs))
+	}
+	pr := prs[0]
+	if pr.Head.Ref != testBranch || pr.Base.Ref != "main" || pr.Title != "chore: weekly schedule" || pr.Draft {
+		t.Fatalf("unexpected PR: %+v", pr)
+	}
+	if len(pr.Assignees) != 1 || pr.Assignees[0].Login != githubtest.Login {
+		t.Fatalf("expected PR to be assigned to %s, got %+v", githubtest.Login, pr.Assignees)
+	}
+	if got := server.BranchFile("acme/daily", testBranch, "schedule.txt"); got != "weekly" {
+		t.Fatalf("expected pushed branch to contain the change, got %q", got)
+	}
+	for _, fullName := range []string{"acme/weekly", "other/daily"} {
+		if n := len(server.PullRequests(fullName)); n != 0 {
+			t.Fatalf("expected no PR for %s, got %d", fullName, n)
+		}
+	}
+
+	// Re-running as draft edits the existing PR instead of creating a new one
+	opts.Draft = true
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	prs = server.PullRequests("acme/daily")
+	if len(prs) != 1 || !prs[0].Draft {
+		t.Fatalf("expected the single PR to become a draft, got %+v", prs)
+	}
+}
+
+func TestRunWorkflow_Gitea(t *testing.T) {
+	server := giteatest.NewServer(t)
+	server.AddRepository(gitea.Repository{FullName: "tooling/daily", Topics: []string{"cron"}}, map[string]string{
+		"schedule.txt": "daily\n",
+	})
+	server.AddRepository(gitea.Repository{FullName: "tooling/other"}, map[string]string{"schedule.txt": "daily\n"})
+
+	cfg := newTestJobConfig()
+	cfg.Search.Gitea = config.GiteaSearch{Host: "forgejo.example.com", Org: "tooling", Topic: "cron"}
+	cfg.PR.Gitea = config.PullRequest{Title: "chore: weekly schedule", Body: "body", Branch: testBranch}
+
+	opts := &CLIOptions{Publish: true, Draft: true, Shell: "sh", Workers: 2}
+	m := newWorkflowManagerForTest(t, cfg, opts)
+	client := gitea.NewClient(server.URL+"/api/v1", giteatest.Token)
+	m.providers.Register(provider.NewGitea("forgejo.example.com", client, m.log))
+
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	prs := server.PullRequests("tooling/daily")
+	if len(prs) != 1 || prs[0].Title != "WIP: chore: weekly schedule" || prs[0].Base.Ref != "main" {
+		t.Fatalf("expected a single draft PR for tooling/daily, got %+v", prs)
+	}
+	if got := server.BranchFile("tooling/daily", testBranch, "schedule.txt"); got != "weekly" {
+		t.Fatalf("expected pushed branch to contain the change, got %q", got)
+	}
+	if n := len(server.PullRequests("tooling/other")); n != 0 {
+		t.Fatalf("expected no PR for tooling/other, got %d", n)
+	}
+
+	// Re-running without -draft marks the existing PR as ready for review
+	opts.Draft = false
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	prs = server.PullRequests("tooling/daily")
+	if len(prs) != 1 || prs[0].Title != "chore: weekly schedule" {
+		t.Fatalf("expected the single PR to become ready for review, got %+v", prs)
+	}
+}
+
+func TestRunWorkflow_Bitbucket(t *testing.T) {
+	server := bitbuckettest.NewServer(t)
+	for _, project := range []string{"LEG", "OTHER"} {
+		repo := bitbucket.Repository{Slug: "daily", Project: bitbucket.Project{Key: project}}
+		server.AddRepository(repo, map[string]string{"schedule.txt": "daily\n"})
+	}
+	server.AddUser("jdoe")
+
+	cfg := newTestJobConfig()
+	cfg.Search.Bitbucket = config.BitbucketSearch{Host: "bitbucket.example.com", Projects: []string{"LEG"}}
+	cfg.PR.Bitbucket = config.PullRequest{
+		Title: "chore: weekly schedule", Body: "body", Branch: testBranch, Reviewers: []string{"jdoe"},
+	}
+
+	opts := &CLIOptions{Publish: true, Draft: true, Shell: "sh", Workers: 2}
+	m := newWorkflowManagerForTest(t, cfg, opts)
+	client := bitbucket.NewClient(server.URL+"/rest/api/1.0", bitbuckettest.Token)
+	m.providers.Register(provider.NewBitbucket("bitbucket.example.com", client, m.log))
+
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(m.reposDir, "bitbucket.example.com", "LEG", "daily", ".git")); err != nil {
+		t.Fatalf("expected the repository to be cloned below its project: %v", err)
+	}
+	prs := server.PullRequests("LEG/daily")
+	if len(prs) != 1 || !prs[0].Draft || prs[0].ToRef.ID != "refs/heads/main" {
+		t.Fatalf("expected a single draft PR for LEG/daily, got %+v", prs)
+	}
+	if reviewers := prs[0].ReviewerNames(); len(reviewers) != 1 || reviewers[0] != "jdoe" {
+		t.Fatalf("expected jdoe to be requested as reviewer, got %v", reviewers)
+	}
+	if got := server.BranchFile("LEG/daily", testBranch, "schedule.txt"); got != "weekly" {
+		t.Fatalf("expected pushed branch to contain the change, got %q", got)
+	}
+	if n := len(server.PullRequests("OTHER/daily")); n != 0 {
+		t.Fatalf("expected no PR for OTHER/daily, got %d", n)
+	}
+
+	// Re-running without -draft marks the existing PR as ready for review
+	opts.Draft = false
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	prs = server.PullRequests("LEG/daily")
+	if len(prs) != 1 || prs[0].Draft || len(prs[0].Reviewers) != 1 {
+		t.Fatalf("expected the single PR to become ready for review, got %+v", prs)
+	}
+}
+
+func TestRunWorkflow_AzureDevOps(t *testing.T) {
+	server := azuredevopstest.NewServer(t)
+	for _, name := range []string{"daily", "weekly"} {
+		repo := azuredevops.Repository{Name: name, Project: azuredevops.Project{Name: "Platform"}}
+		server.AddRepository("acme", repo, map[string]string{"schedule.txt": "daily\n"})
+	}
+	server.AddRepository("acme", azuredevops.Repository{Name: "daily", Project: azuredevops.Project{Name: "Other"}},
+		map[string]string{"schedule.txt": "daily\n"})
+	jdoe := server.AddUser("jdoe")
+
+	cfg := newTestJobConfig()
+	cfg.Search.AzureDevOps = config.AzureDevOpsSearch{
+		Host: "tfs.example.com", Organization: "acme", Projects: []string{"Platform"}, Query: "daily",
+	}
+	cfg.PR.AzureDevOps = config.PullRequest{
+		Title: "chore: weekly schedule", Body: "body", Branch: testBranch, Reviewers: []string{"jdoe"},
+	}
+
+	opts := &CLIOptions{Publish: true, Draft: true, Shell: "sh", Workers: 2}
+	m := newWorkflowManagerForTest(t, cfg, opts)
+	client := azuredevops.NewClient(server.URL, azuredevopstest.Token)
+	m.providers.Register(provider.NewAzureDevOps("tfs.example.com", client, m.log))
+
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	clone := filepath.Join(m.reposDir, "tfs.example.com", "acme", "Platform", "daily")
+	if _, err := os.Stat(filepath.Join(clone, ".git")); err != nil {
+		t.Fatalf("expected the repository to be cloned below its organization and project: %v", err)
+	}
+	prs := server.PullRequests("acme/Platform/daily")
+	if len(prs) != 1 || !prs[0].IsDraft || prs[0].TargetRefName != "refs/heads/main" {
+		t.Fatalf("expected a single draft PR for acme/Platform/daily, got %+v", prs)
+	}
+	if ids := prs[0].ReviewerIDs(); len(ids) != 1 || ids[0] != jdoe {
+		t.Fatalf("expected jdoe to be requested as reviewer, got %v", ids)
+	}
+	if got := server.BranchFile("acme/Platform/daily", testBranch, "schedule.txt"); got != "weekly" {
+		t.Fatalf("expected pushed branch to contain the change, got %q", got)
+	}
+	for _, name := range []string{"acme/Platform/weekly", "acme/Other/daily"} {
+		if n := len(server.PullRequests(name)); n != 0 {
+			t.Fatalf("expected no PR for %s, got %d", name, n)
+		}
+	}
+
+	// Re-running without -draft marks the existing PR as ready for review
+	opts.Draft = false
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	prs = server.PullRequests("acme/Platform/daily")
+	if len(prs) != 1 || prs[0].IsDraft || len(prs[0].Reviewers) != 1 {
+		t.Fatalf("expected the single PR to become ready for review, got %+v", prs)
+	}
+}
+
+func TestRunWorkflow_Local(t *testing.T) {
+	remotes := gittest.New(t)
+	remotes.Create("acme/daily.git", "main", map[string]string{"schedule.txt": "daily\n"})
+	remotes.Create("acme/weekly.git", "main", map[string]string{"schedule.txt": "weekly\n"})
+
+	cfg := newTestJobConfig()
+	cfg.Search.Local = config.LocalSearch{Dir: remotes.Dir()}
+
+	m := newWorkflowManagerForTest(t, cfg, &CLIOptions{Publish: true, Shell: "sh", Workers: 2})
+	m.providers.Register(provider.NewLocal(remotes.Dir(), []config.LocalSearch{cfg.Search.Local}))
+
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The branch is pushed back to the local repository, without a pull request
+	if got := remotes.File("acme/daily.git", testBranch, "schedule.txt"); got != "weekly" {
+		t.Fatalf("expected pushed branch to contain the change, got %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(m.reposDir, provider.LocalHost, "acme", "weekly")); err != nil {
+		t.Fatalf("expected acme/weekly to be cloned: %v", err)
+	}
+}
+
+func TestRunWorkflow_LocalFlatDir(t *testing.T) {
+	remotes := gittest.New(t)
+	remotes.Create("api.git", "main", map[string]string{"schedule.txt": "daily\n"})
+
+	cfg := newTestJobConfig()
+	cfg.Search.Local = config.LocalSearch{Dir: remotes.Dir()}
+
+	m := newWorkflowManagerForTest(t, cfg, &CLIOptions{Publish: true, Shell: "sh", Workers: 2})
+	m.providers.Register(provider.NewLocal(remotes.Dir(), []config.LocalSearch{cfg.Search.Local}))
+
+	if err := m.RunWorkflow(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Repositories directly in the directory are owned by it, so that they are identified after cloning
+	if got := remotes.File("api.git", testBranch, "schedule.txt"); got != "weekly" {
+		t.Fatalf("expected pushed branch to contain the change, got %q", got)
+	}
+}
@@ -0,0 +1,216 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/fredrikaverpil/multipr/internal/azuredevops"
+	"github.com/fredrikaverpil/multipr/internal/config"
+	"github.com/fredrikaverpil/multipr/internal/log"
+)
+
+// AzureDevOps is an Azure DevOps Services (or Azure DevOps Server) instance, backed by the Azure DevOps REST API.
+// Repositories are named by organization, project and repository, e.g. "acme/Platform/api".
+type AzureDevOps struct {
+	host   string
+	client *azuredevops.Client
+	log    *log.Logger
+}
+
+// NewAzureDevOps creates a new Azure DevOps provider for the host.
+func NewAzureDevOps(host string, client *azuredevops.Client, logger *log.Logger) *AzureDevOps {
+	return &AzureDevOps{host: host, client: client, log: logger}
+}
+
+func (a *AzureDevOps) Kind() string {
+	return KindAzureDevOps
+}
+
+func (a *AzureDevOps) Host() string {
+	return a.host
+}
+
+func (a *AzureDevOps) Search(ctx context.Context, search config.SearchSource, _ SearchOptions) ([]Repository, error) {
+	s := search.AzureDevOps
+	if !s.Enabled() || s.HostOrDefault() != a.host {
+		return nil, nil
+	}
+
+	a.log.Info(fmt.Sprintf(
+		"Searching Azure DevOps (%s) in organization %s with projects %v and query: %s",
+		a.host, s.Organization, s.Projects, s.Query,
+	))
+
+	projects := s.Projects
+	if len(projects) == 0 {
+		projects = []string{""} // all projects of the organization
+	}
+
+	var repos []Repository
+	for _, project := range projects {
+		found, err := a.client.ListRepositories(ctx, s.Organization, project)
+		if err != nil {
+			return nil, fmt.Errorf("failed Azure DevOps search: %w", err)
+		}
+		// Repositories cannot be filtered by name in the API
+		for _, r := range found {
+			if strings.Contains(strings.ToLower(r.Name), strings.ToLower(s.Query)) {
+				repos = append(repos, a.toRepository(s.Organization, r))
+			}
+		}
+	}
+
+	return repos, nil
+}
+
+func (a *AzureDevOps) Repository(ctx context.Context, fullName string) (*Repository, error) {
+	r, err := a.client.GetRepository(ctx, fullName)
+	if err != nil {
+		return nil, err
+	}
+	org, _, _ := azuredevops.SplitFullName(fullName)
+	repo := a.toRepository(org, *r)
+	return &repo, nil
+}
+
+// toRepository converts a repository. Disabled repositories cannot be cloned, so they are reported as archived.
+// Azure DevOps does not expose the primary language, topics or push time of repositories, so they are left empty.
+func (a *AzureDevOps) toRepository(org string, r azuredevops.Repository) Repository {
+	return Repository{
+		Host:          a.host,
+		FullName:      r.FullName(org),
+		HasMetadata:   true,
+		DefaultBranch: strings.TrimPrefix(r.DefaultBranch, "refs/heads/"),
+		Archived:      r.IsDisabled,
+		Disabled:      r.IsDisabled,
+		Fork:          r.IsFork,
+		Visibility:    r.Project.Visibility,
+	}
+}
+
+func (a *AzureDevOps) CloneURL(fullName string) string {
+	return a.client.CloneURL(fullName)
+}
+
+// GitEnv passes the personal access token to git as the password of an empty user name, like to the API.
+// Without a token, git falls back to its own credentials.
+func (a *AzureDevOps) GitEnv() []string {
+	token := a.client.Token()
+	if token == "" {
+		return nil
+	}
+	auth := base64.StdEncoding.EncodeToString([]byte(":" + token))
+	return gitAuthEnv(a.client.WebURL(), "Basic "+auth)
+}
+
+func (a *AzureDevOps) CompareURL(fullName, base, branch string) string {
+	params := url.Values{}
+	params.Set("baseVersion", "GB"+base)
+	params.Set("targetVersion", "GB"+branch)
+	return a.client.CloneURL(fullName) + "/branchCompare?" + params.Encode()
+}
+
+func (a *AzureDevOps) FindPR(ctx context.Context, fullName, branch string) (*PullRequest, error) {
+	pr, err := a.client.FindPullRequest(ctx, fullName, branch)
+	if err != nil || pr == nil {
+		return nil, err
+	}
+	return a.toPR(fullName, pr), nil
+}
+
+// CreatePR opens a pull request against the default branch, requesting a review from the reviewers.
+func (a *AzureDevOps) CreatePR(ctx context.Context, fullName string, opts PROptions) (*PullRequest, error) {
+	repo, err := a.client.GetRepository(ctx, fullName)
+	if err != nil {
+		return nil, err
+	}
+	reviewerIDs, err := a.reviewerIDs(ctx, fullName, opts.Reviewers)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, err := a.client.CreatePullRequest(ctx, fullName, azuredevops.CreatePullRequestOptions{
+		Title:        opts.Title,
+		Description:  opts.Body,
+		SourceBranch: opts.Branch,
+		TargetBranch: strings.TrimPrefix(repo.DefaultBranch, "refs/heads/"),
+		ReviewerIDs:  reviewerIDs,
+		Draft:        opts.Draft,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a.toPR(fullName, pr), nil
+}
+
+// EditPR updates the title and description of the pull request, and requests a review from reviewers
+// which have not been requested yet. Reviewers which were added by hand are kept.
+func (a *AzureDevOps) EditPR(ctx context.Context, fullName string, pr *PullRequest, opts PROptions) error {
+	reviewerIDs, err := a.reviewerIDs(ctx, fullName, opts.Reviewers)
+	if err != nil {
+		return err
+	}
+
+	updated, err := a.client.UpdatePullRequest(ctx, fullName, pr.Number, azuredevops.UpdatePullRequestOptions{
+		Title:       &opts.Title,
+		Description: &opts.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, id := range reviewerIDs {
+		if slices.Contains(updated.ReviewerIDs(), id) {
+			continue
+		}
+		if err = a.client.AddReviewer(ctx, fullName, pr.Number, id); err != nil {
+			return err
+		}
+	}
+
+	*pr = *a.toPR(fullName, updated)
+	return nil
+}
+
+func (a *AzureDevOps) SetDraft(ctx context.Context, fullName string, pr *PullRequest, draft bool) error {
+	if pr.Draft == draft {
+		return nil
+	}
+
+	updated, err := a.client.UpdatePullRequest(ctx, fullName, pr.Number, azuredevops.UpdatePullRequestOptions{
+		Draft: &draft,
+	})
+	if err != nil {
+		return err
+	}
+	*pr = *a.toPR(fullName, updated)
+	return nil
+}
+
+// reviewerIDs resolves the user names (or email addresses) of reviewers to identity IDs.
+func (a *AzureDevOps) reviewerIDs(ctx context.Context, fullName string, reviewers []string) ([]string, error) {
+	org, _, _ := azuredevops.SplitFullName(fullName)
+
+	ids := make([]string, 0, len(reviewers))
+	for _, reviewer := range reviewers {
+		identity, err := a.client.FindIdentity(ctx, org, reviewer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find reviewer %s: %w", reviewer, err)
+		}
+		ids = append(ids, identity.ID)
+	}
+	return ids, nil
+}
+
+func (a *AzureDevOps) toPR(fullName string, pr *azuredevops.PullRequest) *PullRequest {
+	return &PullRequest{
+		Number: pr.ID,
+		Title:  pr.Title,
+		URL:    a.client.PullRequestURL(fullName, pr.ID),
+		Draft:  pr.IsDraft,
+	}
+}
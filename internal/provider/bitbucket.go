@@ -0,0 +1,214 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/fredrikaverpil/multipr/internal/bitbucket"
+	"github.com/fredrikaverpil/multipr/internal/config"
+	"github.com/fredrikaverpil/multipr/internal/log"
+)
+
+// Bitbucket is a Bitbucket Server (or Data Center) instance, backed by the Bitbucket REST API.
+// Repositories are named by project key and slug, e.g. "PLAT/api".
+type Bitbucket struct {
+	host   string
+	client *bitbucket.Client
+	log    *log.Logger
+}
+
+// NewBitbucket creates a new Bitbucket provider for the host.
+func NewBitbucket(host string, client *bitbucket.Client, logger *log.Logger) *Bitbucket {
+	return &Bitbucket{host: host, client: client, log: logger}
+}
+
+func (b *Bitbucket) Kind() string {
+	return KindBitbucket
+}
+
+func (b *Bitbucket) Host() string {
+	return b.host
+}
+
+func (b *Bitbucket) Search(ctx context.Context, search config.SearchSource, _ SearchOptions) ([]Repository, error) {
+	s := search.Bitbucket
+	if !s.Enabled() || s.Host != b.host {
+		return nil, nil
+	}
+
+	b.log.Info(fmt.Sprintf(
+		"Searching Bitbucket (%s) with projects %v and query: %s",
+		b.host, s.Projects, s.Query,
+	))
+
+	var found []bitbucket.Repository
+	if len(s.Projects) == 0 {
+		repos, err := b.client.ListRepositories(ctx, bitbucket.ListRepositoriesOptions{Name: s.Query})
+		if err != nil {
+			return nil, fmt.Errorf("failed Bitbucket search: %w", err)
+		}
+		found = repos
+	}
+	for _, project := range s.Projects {
+		repos, err := b.client.ListRepositories(ctx, bitbucket.ListRepositoriesOptions{Project: project})
+		if err != nil {
+			return nil, fmt.Errorf("failed Bitbucket search: %w", err)
+		}
+		// Repositories of a project cannot be filtered by name
+		for _, r := range repos {
+			if strings.Contains(strings.ToLower(r.Name), strings.ToLower(s.Query)) {
+				found = append(found, r)
+			}
+		}
+	}
+
+	repos := make([]Repository, 0, len(found))
+	for _, r := range found {
+		repos = append(repos, b.toRepository(r))
+	}
+
+	return repos, nil
+}
+
+func (b *Bitbucket) Repository(ctx context.Context, fullName string) (*Repository, error) {
+	r, err := b.client.GetRepository(ctx, fullName)
+	if err != nil {
+		return nil, err
+	}
+	repo := b.toRepository(*r)
+	return &repo, nil
+}
+
+// toRepository converts a repository. Bitbucket does not expose the default branch, primary language,
+// topics or push time of repositories in listings, so they are left empty.
+func (b *Bitbucket) toRepository(r bitbucket.Repository) Repository {
+	visibility := "private"
+	if r.Public {
+		visibility = "public"
+	}
+
+	return Repository{
+		Host:        b.host,
+		FullName:    r.FullName(),
+		HasMetadata: true,
+		Archived:    r.Archived,
+		Fork:        r.Origin != nil,
+		Visibility:  visibility,
+	}
+}
+
+func (b *Bitbucket) CloneURL(fullName string) string {
+	return b.client.CloneURL(fullName)
+}
+
+// GitEnv passes the HTTP access token to git as a bearer token, like to the API.
+// Without a token, git falls back to its own credentials.
+func (b *Bitbucket) GitEnv() []string {
+	token := b.client.Token()
+	if token == "" {
+		return nil
+	}
+	return gitAuthEnv(b.client.WebURL(), "Bearer "+token)
+}
+
+func (b *Bitbucket) CompareURL(fullName, base, branch string) string {
+	project, slug, _ := strings.Cut(fullName, "/")
+	params := url.Values{}
+	params.Set("sourceBranch", "refs/heads/"+branch)
+	params.Set("targetBranch", "refs/heads/"+base)
+	return fmt.Sprintf("%s/projects/%s/repos/%s/compare/commits?%s", b.client.WebURL(), project, slug, params.Encode())
+}
+
+func (b *Bitbucket) FindPR(ctx context.Context, fullName, branch string) (*PullRequest, error) {
+	pr, err := b.client.FindPullRequest(ctx, fullName, branch)
+	if err != nil || pr == nil {
+		return nil, err
+	}
+	return bitbucketToPR(pr), nil
+}
+
+// CreatePR opens a pull request against the default branch, requesting a review from the reviewers.
+func (b *Bitbucket) CreatePR(ctx context.Context, fullName string, opts PROptions) (*PullRequest, error) {
+	branch, err := b.client.DefaultBranch(ctx, fullName)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, err := b.client.CreatePullRequest(ctx, fullName, bitbucket.CreatePullRequestOptions{
+		Title:       opts.Title,
+		Description: opts.Body,
+		FromBranch:  opts.Branch,
+		ToBranch:    branch.DisplayID,
+		Reviewers:   opts.Reviewers,
+		Draft:       opts.Draft,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bitbucketToPR(pr), nil
+}
+
+// EditPR updates the pull request, including its draft state. Reviewers which were added to the
+// pull request by hand are kept.
+func (b *Bitbucket) EditPR(ctx context.Context, fullName string, pr *PullRequest, opts PROptions) error {
+	current, err := b.client.GetPullRequest(ctx, fullName, pr.Number)
+	if err != nil {
+		return err
+	}
+
+	reviewers := current.ReviewerNames()
+	for _, reviewer := range opts.Reviewers {
+		if !slices.Contains(reviewers, reviewer) {
+			reviewers = append(reviewers, reviewer)
+		}
+	}
+
+	updated, err := b.client.UpdatePullRequest(ctx, fullName, pr.Number, bitbucket.UpdatePullRequestOptions{
+		Version:     current.Version,
+		Title:       opts.Title,
+		Description: opts.Body,
+		Reviewers:   reviewers,
+		Draft:       opts.Draft,
+	})
+	if err != nil {
+		return err
+	}
+	*pr = *bitbucketToPR(updated)
+	return nil
+}
+
+func (b *Bitbucket) SetDraft(ctx context.Context, fullName string, pr *PullRequest, draft bool) error {
+	if pr.Draft == draft {
+		return nil
+	}
+
+	// Updates replace the whole pull request, so the current state is sent along with the draft state
+	current, err := b.client.GetPullRequest(ctx, fullName, pr.Number)
+	if err != nil {
+		return err
+	}
+	updated, err := b.client.UpdatePullRequest(ctx, fullName, pr.Number, bitbucket.UpdatePullRequestOptions{
+		Version:     current.Version,
+		Title:       current.Title,
+		Description: current.Description,
+		Reviewers:   current.ReviewerNames(),
+		Draft:       draft,
+	})
+	if err != nil {
+		return err
+	}
+	*pr = *bitbucketToPR(updated)
+	return nil
+}
+
+func bitbucketToPR(pr *bitbucket.PullRequest) *PullRequest {
+	return &PullRequest{
+		Number: pr.ID,
+		Title:  pr.Title,
+		URL:    pr.URL(),
+		Draft:  pr.Draft,
+	}
+}
@@ -0,0 +1,188 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/fredrikaverpil/multipr/internal/config"
+	"github.com/fredrikaverpil/multipr/internal/gitea"
+	"github.com/fredrikaverpil/multipr/internal/log"
+)
+
+// Gitea is a Gitea or Forgejo instance, backed by the Gitea REST API.
+// Gitea has no draft flag in its API; drafts are marked with a "WIP: " title prefix.
+type Gitea struct {
+	host   string
+	client *gitea.Client
+	log    *log.Logger
+}
+
+// NewGitea creates a new Gitea provider for the host.
+func NewGitea(host string, client *gitea.Client, logger *log.Logger) *Gitea {
+	return &Gitea{host: host, client: client, log: logger}
+}
+
+func (g *Gitea) Kind() string {
+	return KindGitea
+}
+
+func (g *Gitea) Host() string {
+	return g.host
+}
+
+func (g *Gitea) Search(ctx context.Context, search config.SearchSource, _ SearchOptions) ([]Repository, error) {
+	s := search.Gitea
+	if !s.Enabled() || s.Host != g.host {
+		return nil, nil
+	}
+
+	g.log.Info(fmt.Sprintf(
+		"Searching Gitea (%s) with org '%s', topic '%s' and query: %s",
+		g.host, s.Org, s.Topic, s.Query,
+	))
+
+	opts := gitea.SearchRepositoriesOptions{Query: s.Query}
+	if s.Topic != "" {
+		// A search matches either topics or names, so the query is matched against the names below
+		opts = gitea.SearchRepositoriesOptions{Query: s.Topic, Topic: true}
+	}
+	if s.Org != "" {
+		owner, err := g.client.GetUser(ctx, s.Org)
+		if err != nil {
+			return nil, fmt.Errorf("failed Gitea search: %w", err)
+		}
+		opts.OwnerID = owner.ID
+	}
+
+	found, err := g.client.SearchRepositories(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed Gitea search: %w", err)
+	}
+
+	repos := make([]Repository, 0, len(found))
+	for _, r := range found {
+		_, name, _ := strings.Cut(r.FullName, "/")
+		if s.Topic != "" && !strings.Contains(strings.ToLower(name), strings.ToLower(s.Query)) {
+			continue
+		}
+		repos = append(repos, g.toRepository(r))
+	}
+
+	return repos, nil
+}
+
+func (g *Gitea) Repository(ctx context.Context, fullName string) (*Repository, error) {
+	r, err := g.client.GetRepository(ctx, fullName)
+	if err != nil {
+		return nil, err
+	}
+	repo := g.toRepository(*r)
+	return &repo, nil
+}
+
+// toRepository converts a repository. Gitea does not expose when a repository was last pushed to,
+// so the time it was last updated is used instead.
+func (g *Gitea) toRepository(r gitea.Repository) Repository {
+	visibility := "public"
+	switch {
+	case r.Private:
+		visibility = "private"
+	case r.Internal:
+		visibility = "internal"
+	}
+
+	return Repository{
+		Host:          g.host,
+		FullName:      r.FullName,
+		HasMetadata:   true,
+		DefaultBranch: r.DefaultBranch,
+		Archived:      r.Archived,
+		Fork:          r.Fork,
+		Template:      r.Template,
+		Visibility:    visibility,
+		Language:      r.Language,
+		Topics:        r.Topics,
+		PushedAt:      r.UpdatedAt,
+	}
+}
+
+func (g *Gitea) CloneURL(fullName string) string {
+	return fmt.Sprintf("%s/%s.git", g.client.WebURL(), fullName)
+}
+
+// GitEnv passes the API token to git in the same header as to the API, which Gitea and Forgejo also accept
+// for git over HTTPS. Without a token, git falls back to its own credentials.
+func (g *Gitea) GitEnv() []string {
+	token := g.client.Token()
+	if token == "" {
+		return nil
+	}
+	return gitAuthEnv(g.client.WebURL(), "token "+token)
+}
+
+func (g *Gitea) CompareURL(fullName, base, branch string) string {
+	return fmt.Sprintf("%s/%s/compare/%s...%s", g.client.WebURL(), fullName, base, branch)
+}
+
+func (g *Gitea) FindPR(ctx context.Context, fullName, branch string) (*PullRequest, error) {
+	pr, err := g.client.FindPullRequest(ctx, fullName, branch)
+	if err != nil || pr == nil {
+		return nil, err
+	}
+	return giteaToPR(pr), nil
+}
+
+func (g *Gitea) CreatePR(ctx context.Context, fullName string, opts PROptions) (*PullRequest, error) {
+	repo, err := g.client.GetRepository(ctx, fullName)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, err := g.client.CreatePullRequest(ctx, fullName, gitea.CreatePullRequestOptions{
+		Title: gitea.DraftTitle(opts.Title, opts.Draft),
+		Head:  opts.Branch,
+		Base:  repo.DefaultBranch,
+		Body:  opts.Body,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return giteaToPR(pr), nil
+}
+
+func (g *Gitea) EditPR(ctx context.Context, fullName string, pr *PullRequest, opts PROptions) error {
+	updated, err := g.client.UpdatePullRequest(ctx, fullName, pr.Number, gitea.UpdatePullRequestOptions{
+		Title: gitea.DraftTitle(opts.Title, opts.Draft),
+		Body:  opts.Body,
+	})
+	if err != nil {
+		return err
+	}
+	*pr = *giteaToPR(updated)
+	return nil
+}
+
+func (g *Gitea) SetDraft(ctx context.Context, fullName string, pr *PullRequest, draft bool) error {
+	if pr.Draft == draft {
+		return nil
+	}
+
+	updated, err := g.client.UpdatePullRequest(ctx, fullName, pr.Number, gitea.UpdatePullRequestOptions{
+		Title: gitea.DraftTitle(pr.Title, draft),
+	})
+	if err != nil {
+		return err
+	}
+	*pr = *giteaToPR(updated)
+	return nil
+}
+
+func giteaToPR(pr *gitea.PullRequest) *PullRequest {
+	return &PullRequest{
+		Number: pr.Number,
+		Title:  pr.Title,
+		URL:    pr.HTMLURL,
+		Draft:  gitea.IsDraft(pr.Title),
+	}
+}
@@ -0,0 +1,251 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/fredrikaverpil/multipr/internal/config"
+	"github.com/fredrikaverpil/multipr/internal/github"
+	"github.com/fredrikaverpil/multipr/internal/log"
+)
+
+const (
+	DefaultGitHubHost = github.DefaultHost
+
+	GitProtocolHTTPS = "https"
+	GitProtocolSSH   = "ssh"
+)
+
+// GitHub is a GitHub instance, backed by the GitHub REST and GraphQL APIs.
+type GitHub struct {
+	host        string
+	client      *github.Client
+	gitProtocol func() string // the protocol of clone URLs, as configured with `gh config set git_protocol`
+	log         *log.Logger
+
+	loginMu sync.Mutex
+	login   string // the authenticated user, which pull requests are assigned to
+}
+
+// NewGitHub creates a new GitHub provider for the host, which clones over the protocol returned by gitProtocol
+// (GitProtocolHTTPS or GitProtocolSSH, and HTTPS if empty). It is only called once, when a repository is cloned.
+func NewGitHub(host string, client *github.Client, gitProtocol func() string, logger *log.Logger) *GitHub {
+	return &GitHub{host: host, client: client, gitProtocol: sync.OnceValue(gitProtocol), log: logger}
+}
+
+func (g *GitHub) Kind() string {
+	return KindGitHub
+}
+
+func (g *GitHub) Host() string {
+	return g.host
+}
+
+func (g *GitHub) Search(ctx context.Context, search config.SearchSource, _ SearchOptions) ([]Repository, error) {
+	method := search.GitHub.Method
+	query := search.GitHub.Query
+	if method == "" || search.GitHub.HostOrDefault() != g.host {
+		return nil, nil
+	}
+
+	if method == "org" {
+		return g.listOwnerRepos(ctx, search.GitHub.Owners)
+	}
+
+	g.log.Info(fmt.Sprintf("Searching GitHub (%s) using method '%s' with query: %s", g.host, method, query))
+
+	endpoint := github.SearchCode
+	var err error
+
+	switch method {
+	case "code":
+		query, err = cliSearchQuery(method, query)
+	case "repos":
+		endpoint = github.SearchRepositories
+		query, err = cliSearchQuery(method, query)
+	case "api":
+		if search.GitHub.Endpoint != "" {
+			endpoint = github.SearchEndpoint(search.GitHub.Endpoint)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported GitHub search method: %s", method)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := g.searchAll(ctx, endpoint, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed GitHub search: %w", err)
+	}
+
+	repos := make([]Repository, 0, len(found))
+	for _, r := range found {
+		if endpoint == github.SearchRepositories {
+			repos = append(repos, g.toRepository(r))
+			continue
+		}
+		// Code search results lack the repository metadata
+		repos = append(repos, Repository{Host: g.host, FullName: r.FullName})
+	}
+
+	return repos, nil
+}
+
+// listOwnerRepos lists every repository of the owners, including their metadata.
+func (g *GitHub) listOwnerRepos(ctx context.Context, owners []string) ([]Repository, error) {
+	if len(owners) == 0 {
+		return nil, errors.New("GitHub search method 'org' requires at least one owner")
+	}
+
+	var repos []Repository
+	for _, owner := range owners {
+		g.log.Info(fmt.Sprintf("Listing all GitHub (%s) repositories of %s", g.host, owner))
+
+		ghRepos, err := g.client.ListOwnerRepositories(ctx, owner)
+		if err != nil {
+			return nil, fmt.Errorf("failed GitHub search: %w", err)
+		}
+
+		for _, r := range ghRepos {
+			repos = append(repos, g.toRepository(r))
+		}
+	}
+
+	return repos, nil
+}
+
+func (g *GitHub) Repository(ctx context.Context, fullName string) (*Repository, error) {
+	r, err := g.client.GetRepository(ctx, fullName)
+	if err != nil {
+		return nil, err
+	}
+	repo := g.toRepository(*r)
+	return &repo, nil
+}
+
+func (g *GitHub) toRepository(r github.Repository) Repository {
+	return Repository{
+		Host:          g.host,
+		FullName:      r.FullName,
+		HasMetadata:   true,
+		DefaultBranch: r.DefaultBranch,
+		Archived:      r.Archived || r.Disabled,
+		Disabled:      r.Disabled,
+		Fork:          r.Fork,
+		Template:      r.IsTemplate,
+		Visibility:    r.Visibility,
+		Language:      r.Language,
+		Topics:        r.Topics,
+		PushedAt:      r.PushedAt,
+	}
+}
+
+func (g *GitHub) CloneURL(fullName string) string {
+	if g.gitProtocol() == GitProtocolSSH {
+		return fmt.Sprintf("git@%s:%s.git", g.host, fullName)
+	}
+	return fmt.Sprintf("%s/%s.git", g.client.WebURL(), fullName)
+}
+
+// GitEnv passes the API token to git as an HTTP header for the instance, the same way the GitHub CLI
+// authenticates git. Without a token, git falls back to its own credentials.
+func (g *GitHub) GitEnv() []string {
+	token := g.client.Token()
+	if token == "" {
+		return nil
+	}
+	auth := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + token))
+	return gitAuthEnv(g.client.WebURL(), "Basic "+auth)
+}
+
+func (g *GitHub) CompareURL(fullName, base, branch string) string {
+	return fmt.Sprintf("%s/%s/compare/%s...%s", g.client.WebURL(), fullName, base, branch)
+}
+
+func (g *GitHub) FindPR(ctx context.Context, fullName, branch string) (*PullRequest, error) {
+	pr, err := g.client.FindPullRequest(ctx, fullName, branch)
+	if err != nil || pr == nil {
+		return nil, err
+	}
+	return toPullRequest(pr), nil
+}
+
+// CreatePR opens a pull request against the default branch, assigned to the authenticated user.
+func (g *GitHub) CreatePR(ctx context.Context, fullName string, opts PROptions) (*PullRequest, error) {
+	repo, err := g.client.GetRepository(ctx, fullName)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, err := g.client.CreatePullRequest(ctx, fullName, github.CreatePullRequestOptions{
+		Title: opts.Title,
+		Head:  opts.Branch,
+		Base:  repo.DefaultBranch,
+		Body:  opts.Body,
+		Draft: opts.Draft,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The pull request exists at this point, so failing to assign it should not fail the run
+	if err = g.assignToSelf(ctx, fullName, pr.Number); err != nil {
+		g.log.Warn(fmt.Sprintf("Failed to assign PR #%d in %s: %v", pr.Number, fullName, err))
+	}
+
+	return toPullRequest(pr), nil
+}
+
+func (g *GitHub) assignToSelf(ctx context.Context, fullName string, number int) error {
+	g.loginMu.Lock()
+	if g.login == "" {
+		user, err := g.client.AuthenticatedUser(ctx)
+		if err != nil {
+			g.loginMu.Unlock()
+			return err
+		}
+		g.login = user.Login
+	}
+	login := g.login
+	g.loginMu.Unlock()
+
+	return g.client.AddAssignees(ctx, fullName, number, []string{login})
+}
+
+func (g *GitHub) EditPR(ctx context.Context, fullName string, pr *PullRequest, opts PROptions) error {
+	updated, err := g.client.UpdatePullRequest(ctx, fullName, pr.Number, github.UpdatePullRequestOptions{
+		Title: opts.Title,
+		Body:  opts.Body,
+	})
+	if err != nil {
+		return err
+	}
+	pr.Title = updated.Title
+	return nil
+}
+
+func (g *GitHub) SetDraft(ctx context.Context, fullName string, pr *PullRequest, draft bool) error {
+	// The draft state can only be changed through GraphQL, which identifies the pull request by node ID
+	current, err := g.client.GetPullRequest(ctx, fullName, pr.Number)
+	if err != nil {
+		return err
+	}
+	if err = g.client.SetPullRequestDraft(ctx, current.NodeID, draft); err != nil {
+		return err
+	}
+	pr.Draft = draft
+	return nil
+}
+
+func toPullRequest(pr *github.PullRequest) *PullRequest {
+	return &PullRequest{
+		Number: pr.Number,
+		Title:  pr.Title,
+		URL:    pr.HTMLURL,
+		Draft:  pr.Draft,
+	}
+}
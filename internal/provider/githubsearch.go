@@ -0,0 +1,299 @@
+// Description: slicing of GitHub search queries.
+//
+// The GitHub search API never returns more than 1000 results for a query. To enumerate
+// larger result sets, a query is narrowed down with a range qualifier (e.g. `size:0..1000`),
+// and ranges are split in half until each slice reports at most 1000 results.
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/shlex"
+
+	"github.com/fredrikaverpil/multipr/internal/github"
+	"github.com/fredrikaverpil/multipr/internal/log"
+)
+
+const (
+	searchResultLimit = 1000 // max results returned by GitHub for a single query
+	searchPerPage     = 100  // page size of the search API client
+
+	// maxCodeSearchFileSize is the largest file size indexed by GitHub code search.
+	maxCodeSearchFileSize = 384 * 1024
+	dateLayout            = "2006-01-02"
+	hoursPerDay           = 24
+)
+
+// searchEpoch is the earliest date a repository can have been pushed to.
+func searchEpoch() time.Time {
+	return time.Date(2008, time.January, 1, 0, 0, 0, 0, time.UTC)
+}
+
+type searchPageFunc func(ctx context.Context, query string, page int) (*github.SearchResult, error)
+
+// searchSlice is an inclusive integer range of a search qualifier.
+type searchSlice struct {
+	name   string // qualifier name, e.g. "size"
+	lo, hi int
+	format func(lo, hi int) string
+}
+
+func (s *searchSlice) qualifier() string {
+	return fmt.Sprintf("%s:%s", s.name, s.format(s.lo, s.hi))
+}
+
+// split halves the range, returning false if it cannot be split any further.
+func (s *searchSlice) split() (*searchSlice, *searchSlice, bool) {
+	if s.hi <= s.lo {
+		return nil, nil, false
+	}
+	mid := s.lo + (s.hi-s.lo)/2
+	return &searchSlice{name: s.name, lo: s.lo, hi: mid, format: s.format},
+		&searchSlice{name: s.name, lo: mid + 1, hi: s.hi, format: s.format},
+		true
+}
+
+// newSizeSlice slices code search results by file size in bytes.
+func newSizeSlice() *searchSlice {
+	return &searchSlice{
+		name: "size",
+		lo:   0,
+		hi:   maxCodeSearchFileSize,
+		format: func(lo, hi int) string {
+			return fmt.Sprintf("%d..%d", lo, hi)
+		},
+	}
+}
+
+// newPushedSlice slices repository search results by the day they were last pushed to.
+func newPushedSlice(now time.Time) *searchSlice {
+	epoch := searchEpoch()
+	return &searchSlice{
+		name: "pushed",
+		lo:   0,
+		hi:   int(now.Sub(epoch).Hours() / hoursPerDay),
+		format: func(lo, hi int) string {
+			return fmt.Sprintf("%s..%s",
+				epoch.AddDate(0, 0, lo).Format(dateLayout),
+				epoch.AddDate(0, 0, hi).Format(dateLayout))
+		},
+	}
+}
+
+// searchAll returns all repositories matching the raw search query. Queries matching more than the
+// 1000 results GitHub returns are sliced into smaller queries, by file size (code) or by pushed date
+// (repositories), until every slice can be fully paginated.
+func (g *GitHub) searchAll(
+	ctx context.Context,
+	endpoint github.SearchEndpoint,
+	query string,
+) ([]github.Repository, error) {
+	var slice *searchSlice
+	switch endpoint {
+	case github.SearchCode:
+		slice = newSizeSlice()
+	case github.SearchRepositories:
+		slice = newPushedSlice(time.Now())
+	default:
+		return nil, fmt.Errorf("unsupported GitHub search endpoint: %s", endpoint)
+	}
+
+	if strings.Contains(query, slice.name+":") {
+		g.log.Debug(fmt.Sprintf("Query already contains '%s:', results will not be sliced", slice.name))
+		slice = nil
+	}
+
+	fetch := func(ctx context.Context, q string, page int) (*github.SearchResult, error) {
+		return g.client.Search(ctx, endpoint, q, page)
+	}
+
+	s := &slicedSearch{fetch: fetch, log: g.log, repos: make(map[string]github.Repository)}
+	if err := s.collect(ctx, query, slice, false); err != nil {
+		return nil, err
+	}
+
+	return s.repositories(), nil
+}
+
+// slicedSearch collects unique repositories across all slices of a query.
+type slicedSearch struct {
+	fetch searchPageFunc
+	log   *log.Logger
+	repos map[string]github.Repository
+}
+
+// collect paginates through the query. If the query has more results than can be returned,
+// it is narrowed down with slice, unless sliced is false and the full range has not been applied yet.
+func (s *slicedSearch) collect(ctx context.Context, query string, slice *searchSlice, sliced bool) error {
+	q := query
+	if sliced {
+		q = fmt.Sprintf("%s %s", query, slice.qualifier())
+	}
+
+	first, err := s.fetch(ctx, q, 1)
+	if err != nil {
+		return err
+	}
+
+	if first.TotalCount > searchResultLimit && slice != nil {
+		if !sliced {
+			return s.collect(ctx, query, slice, true)
+		}
+		if a, b, ok := slice.split(); ok {
+			s.log.Debug(fmt.Sprintf("%d results for '%s', splitting into smaller slices", first.TotalCount, q))
+			if err = s.collect(ctx, query, a, true); err != nil {
+				return err
+			}
+			return s.collect(ctx, query, b, true)
+		}
+	}
+
+	if first.TotalCount > searchResultLimit {
+		s.log.Warn(fmt.Sprintf(
+			"Search '%s' has %d results, only the first %d can be retrieved",
+			q, first.TotalCount, searchResultLimit))
+	}
+
+	incomplete := first.IncompleteResults
+	s.add(first)
+	fetched := first.Items
+	for page := 2; first.Items > 0 && fetched < min(first.TotalCount, searchResultLimit); page++ {
+		next, fetchErr := s.fetch(ctx, q, page)
+		if fetchErr != nil {
+			return fetchErr
+		}
+		if next.Items == 0 {
+			break
+		}
+		incomplete = incomplete || next.IncompleteResults
+		s.add(next)
+		fetched += next.Items
+	}
+
+	if incomplete {
+		s.log.Warn(fmt.Sprintf(
+			"GitHub reported incomplete results for '%s' (the search timed out), some repositories may be missing",
+			q))
+	}
+
+	return nil
+}
+
+func (s *slicedSearch) add(page *github.SearchResult) {
+	for _, repo := range page.Repositories {
+		s.repos[repo.FullName] = repo
+	}
+}
+
+// repositories returns the unique repositories, sorted by full name.
+func (s *slicedSearch) repositories() []github.Repository {
+	repos := make([]github.Repository, 0, len(s.repos))
+	for _, repo := range s.repos {
+		repos = append(repos, repo)
+	}
+	sort.Slice(repos, func(i, j int) bool { return repos[i].FullName < repos[j].FullName })
+	return repos
+}
+
+// cliFlags maps the `gh search` flags of each search method to their search qualifiers.
+// Flags which do not translate into a qualifier, e.g. --sort or --limit, are not supported,
+// as all results are enumerated rather than the top results.
+var cliFlags = map[string]map[string]string{
+	"code": {
+		"extension": "extension",
+		"filename":  "filename",
+		"language":  "language",
+		"match":     "in",
+		"owner":     "user",
+		"repo":      "repo",
+		"size":      "size",
+	},
+	"repos": {
+		"archived":           "archived",
+		"created":            "created",
+		"followers":          "followers",
+		"forks":              "forks",
+		"good-first-issues":  "good-first-issues",
+		"help-wanted-issues": "help-wanted-issues",
+		"include-forks":      "fork",
+		"language":           "language",
+		"license":            "license",
+		"match":              "in",
+		"number-topics":      "topics",
+		"owner":              "user",
+		"size":               "size",
+		"stars":              "stars",
+		"topic":              "topic",
+		"updated":            "pushed",
+		"visibility":         "is",
+	},
+}
+
+// cliBoolFlags are the `gh search` flags which take no value.
+var cliBoolFlags = map[string]bool{"archived": true}
+
+// cliSearchQuery converts a query in `gh search` syntax (e.g. `--owner myorg --filename go.mod "my team"`)
+// of the search method into the equivalent search API query (e.g. `user:myorg filename:go.mod "my team"`).
+// Queries without flags are already in search API syntax and are returned as is. Flags which cannot be
+// translated, including short flags, are rejected rather than changing the meaning of the query.
+// Arguments after "--" are search terms, e.g. to exclude a qualifier with `-- -org:myorg`.
+func cliSearchQuery(method, query string) (string, error) {
+	args, err := shlex.Split(query)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse query: %w", err)
+	}
+
+	var terms []string
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--" {
+			for _, arg := range args[i+1:] {
+				terms = append(terms, searchTerm(arg))
+			}
+			break
+		}
+
+		flag, ok := strings.CutPrefix(args[i], "--")
+		if !ok {
+			if len(args[i]) > 1 && strings.HasPrefix(args[i], "-") {
+				return "", fmt.Errorf(
+					"unsupported flag %s in query (use long flags, or '--' before search terms): %s", args[i], query)
+			}
+			terms = append(terms, searchTerm(args[i]))
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(flag, "=")
+		qualifier, ok := cliFlags[method][name]
+		if !ok {
+			return "", fmt.Errorf("unsupported flag --%s for GitHub search method '%s' in query: %s", name, method, query)
+		}
+		switch {
+		case !hasValue && cliBoolFlags[name]:
+			value = "true"
+		case !hasValue:
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("missing value for --%s in query: %s", name, query)
+			}
+			i++
+			value = args[i]
+		}
+		for v := range strings.SplitSeq(value, ",") {
+			terms = append(terms, qualifier+":"+v)
+		}
+	}
+
+	return strings.Join(terms, " "), nil
+}
+
+// searchTerm quotes terms containing whitespace, which were quoted in the query.
+func searchTerm(arg string) string {
+	if strings.ContainsAny(arg, " \t") {
+		return fmt.Sprintf("%q", arg)
+	}
+	return arg
+}
@@ -0,0 +1,170 @@
+package provider //nolint:testpackage // internal testing needed for unexported functions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fredrikaverpil/multipr/internal/github"
+	"github.com/fredrikaverpil/multipr/internal/log"
+)
+
+// fakeCodeSearch returns a search function over files, where each file has a size and
+// belongs to its own repository. It honors the size qualifier and the 1000 result ceiling.
+func fakeCodeSearch(sizes []int, calls *int) searchPageFunc {
+	return func(_ context.Context, query string, page int) (*github.SearchResult, error) {
+		*calls++
+
+		lo, hi := 0, maxCodeSearchFileSize
+		if _, qualifier, ok := strings.Cut(query, "size:"); ok {
+			if _, err := fmt.Sscanf(qualifier, "%d..%d", &lo, &hi); err != nil {
+				return nil, err
+			}
+		}
+
+		var matches []github.Repository
+		for i, size := range sizes {
+			if size >= lo && size <= hi {
+				matches = append(matches, github.Repository{FullName: fmt.Sprintf("acme/repo-%d", i)})
+			}
+		}
+
+		result := &github.SearchResult{TotalCount: len(matches)}
+		start := (page - 1) * searchPerPage
+		end := min(start+searchPerPage, len(matches), searchResultLimit)
+		if start < end {
+			result.Repositories = matches[start:end]
+			result.Items = end - start
+		}
+		return result, nil
+	}
+}
+
+func newTestLogger(t *testing.T) *log.Logger {
+	t.Helper()
+
+	logger, err := log.NewLogger(log.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return logger
+}
+
+func TestSlicedSearch_BelowLimitIsNotSliced(t *testing.T) {
+	sizes := make([]int, 250)
+	calls := 0
+	s := &slicedSearch{fetch: fakeCodeSearch(sizes, &calls), log: newTestLogger(t), repos: map[string]github.Repository{}}
+
+	if err := s.collect(t.Context(), "filename:go.mod", newSizeSlice(), false); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(s.repositories()); got != 250 {
+		t.Fatalf("expected 250 repositories, got %d", got)
+	}
+	if calls != 3 {
+		t.Fatalf("expected 3 page requests, got %d", calls)
+	}
+}
+
+func TestSlicedSearch_AboveLimitIsFullyEnumerated(t *testing.T) {
+	sizes := make([]int, 3500)
+	for i := range sizes {
+		sizes[i] = (i * 97) % 20000
+	}
+	calls := 0
+	s := &slicedSearch{fetch: fakeCodeSearch(sizes, &calls), log: newTestLogger(t), repos: map[string]github.Repository{}}
+
+	if err := s.collect(t.Context(), "filename:go.mod", newSizeSlice(), false); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(s.repositories()); got != len(sizes) {
+		t.Fatalf("expected %d repositories, got %d", len(sizes), got)
+	}
+}
+
+func TestSlicedSearch_UnsplittableSliceIsCapped(t *testing.T) {
+	sizes := make([]int, 1200) // all files have the same size
+	calls := 0
+	s := &slicedSearch{fetch: fakeCodeSearch(sizes, &calls), log: newTestLogger(t), repos: map[string]github.Repository{}}
+
+	if err := s.collect(t.Context(), "filename:go.mod", newSizeSlice(), false); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(s.repositories()); got != searchResultLimit {
+		t.Fatalf("expected %d repositories, got %d", searchResultLimit, got)
+	}
+}
+
+func TestPushedSlice(t *testing.T) {
+	now := time.Date(2008, time.January, 11, 0, 0, 0, 0, time.UTC)
+	slice := newPushedSlice(now)
+
+	if got := slice.qualifier(); got != "pushed:2008-01-01..2008-01-11" {
+		t.Fatalf("unexpected qualifier %q", got)
+	}
+
+	a, b, ok := slice.split()
+	if !ok {
+		t.Fatal("expected slice to be splittable")
+	}
+	if a.qualifier() != "pushed:2008-01-01..2008-01-06" || b.qualifier() != "pushed:2008-01-07..2008-01-11" {
+		t.Fatalf("unexpected split: %s, %s", a.qualifier(), b.qualifier())
+	}
+}
+
+func TestCLISearchQuery(t *testing.T) {
+	tests := []struct {
+		method string
+		query  string
+		want   string
+	}{
+		{
+			method: "code",
+			query:  `--owner myorg --filename CODEOWNERS "my team"`,
+			want:   `user:myorg filename:CODEOWNERS "my team"`,
+		},
+		{
+			method: "repos",
+			query:  `--owner=a,b --archived --visibility private dependabot`,
+			want:   `user:a user:b archived:true is:private dependabot`,
+		},
+		{
+			method: "code",
+			query:  `org:myorg filename:go.mod`,
+			want:   `org:myorg filename:go.mod`,
+		},
+		{
+			method: "code",
+			query:  `--owner myorg -- -repo:myorg/legacy go.mod`,
+			want:   `user:myorg -repo:myorg/legacy go.mod`,
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := cliSearchQuery(tt.method, tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("cliSearchQuery(%q, %q) = %q, want %q", tt.method, tt.query, got, tt.want)
+		}
+	}
+
+	for _, tt := range []struct{ method, query string }{
+		{method: "code", query: "--owner"},
+		{method: "code", query: "--owner myorg -L 100"},
+		{method: "code", query: "--owner myorg --limit 100"},
+		{method: "repos", query: "--owner myorg --sort stars --order desc"},
+		{method: "code", query: "--owner myorg --topic go"},
+		{method: "repos", query: "--owner myorg --unknown value"},
+	} {
+		if _, err := cliSearchQuery(tt.method, tt.query); err == nil {
+			t.Errorf("expected an error for %s query %q", tt.method, tt.query)
+		}
+	}
+}
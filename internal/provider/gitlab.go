@@ -0,0 +1,170 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/fredrikaverpil/multipr/internal/config"
+	"github.com/fredrikaverpil/multipr/internal/gitlab"
+	"github.com/fredrikaverpil/multipr/internal/log"
+)
+
+// GitLab is a GitLab instance, backed by the GitLab REST API.
+// GitLab has no separate draft flag in its API; drafts are marked with a "Draft: " title prefix.
+type GitLab struct {
+	host   string
+	client *gitlab.Client
+	log    *log.Logger
+}
+
+// NewGitLab creates a new GitLab provider for the host.
+func NewGitLab(host string, client *gitlab.Client, logger *log.Logger) *GitLab {
+	return &GitLab{host: host, client: client, log: logger}
+}
+
+func (g *GitLab) Kind() string {
+	return KindGitLab
+}
+
+func (g *GitLab) Host() string {
+	return g.host
+}
+
+func (g *GitLab) Search(ctx context.Context, search config.SearchSource, opts SearchOptions) ([]Repository, error) {
+	s := search.GitLab
+	if !s.Enabled() || s.HostOrDefault() != g.host {
+		return nil, nil
+	}
+
+	g.log.Info(fmt.Sprintf(
+		"Searching GitLab (%s) with group '%s', topic '%s' and query: %s",
+		g.host, s.Group, s.Topic, s.Query,
+	))
+
+	projects, err := g.client.ListProjects(ctx, gitlab.ListProjectsOptions{
+		Group:      s.Group,
+		Topic:      s.Topic,
+		Search:     s.Query,
+		Membership: s.Membership,
+		Archived:   opts.Archived,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed GitLab search: %w", err)
+	}
+
+	repos := make([]Repository, 0, len(projects))
+	for _, project := range projects {
+		repos = append(repos, g.toRepository(project))
+	}
+
+	return repos, nil
+}
+
+func (g *GitLab) Repository(ctx context.Context, fullName string) (*Repository, error) {
+	project, err := g.client.GetProject(ctx, fullName)
+	if err != nil {
+		return nil, err
+	}
+	repo := g.toRepository(*project)
+	return &repo, nil
+}
+
+// toRepository converts a project. GitLab does not expose a primary language, so it is left empty.
+func (g *GitLab) toRepository(project gitlab.Project) Repository {
+	return Repository{
+		Host:          g.host,
+		FullName:      project.PathWithNamespace,
+		HasMetadata:   true,
+		DefaultBranch: project.DefaultBranch,
+		Archived:      project.Archived,
+		Fork:          project.ForkedFromProject != nil,
+		Visibility:    project.Visibility,
+		Topics:        project.Topics,
+		PushedAt:      project.LastActivityAt,
+	}
+}
+
+func (g *GitLab) CloneURL(fullName string) string {
+	return fmt.Sprintf("https://%s/%s.git", g.host, fullName)
+}
+
+// GitEnv passes the API token to git as the password of the oauth2 user, which GitLab accepts for
+// personal, project and group access tokens. Without a token, git falls back to its own credentials.
+func (g *GitLab) GitEnv() []string {
+	token := g.client.Token()
+	if token == "" {
+		return nil
+	}
+	auth := base64.StdEncoding.EncodeToString([]byte("oauth2:" + token))
+	return gitAuthEnv("https://"+g.host, "Basic "+auth)
+}
+
+func (g *GitLab) CompareURL(fullName, base, branch string) string {
+	return fmt.Sprintf("https://%s/%s/-/compare/%s...%s", g.host, fullName, base, branch)
+}
+
+func (g *GitLab) FindPR(ctx context.Context, fullName, branch string) (*PullRequest, error) {
+	mr, err := g.client.FindMergeRequest(ctx, fullName, branch)
+	if err != nil {
+		return nil, err
+	}
+	if mr == nil {
+		return nil, nil //nolint:nilnil // no merge request is not an error
+	}
+	return mergeRequestToPR(mr), nil
+}
+
+func (g *GitLab) CreatePR(ctx context.Context, fullName string, opts PROptions) (*PullRequest, error) {
+	project, err := g.client.GetProject(ctx, fullName)
+	if err != nil {
+		return nil, err
+	}
+
+	mr, err := g.client.CreateMergeRequest(ctx, fullName, gitlab.CreateMergeRequestOptions{
+		SourceBranch: opts.Branch,
+		TargetBranch: project.DefaultBranch,
+		Title:        gitlab.DraftTitle(opts.Title, opts.Draft),
+		Description:  opts.Body,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mergeRequestToPR(mr), nil
+}
+
+func (g *GitLab) EditPR(ctx context.Context, fullName string, pr *PullRequest, opts PROptions) error {
+	mr, err := g.client.UpdateMergeRequest(ctx, fullName, pr.Number, gitlab.UpdateMergeRequestOptions{
+		Title:       gitlab.DraftTitle(opts.Title, opts.Draft),
+		Description: opts.Body,
+	})
+	if err != nil {
+		return err
+	}
+	*pr = *mergeRequestToPR(mr)
+	return nil
+}
+
+func (g *GitLab) SetDraft(ctx context.Context, fullName string, pr *PullRequest, draft bool) error {
+	if pr.Draft == draft {
+		return nil
+	}
+
+	mr, err := g.client.UpdateMergeRequest(ctx, fullName, pr.Number, gitlab.UpdateMergeRequestOptions{
+		Title: gitlab.DraftTitle(pr.Title, draft),
+	})
+	if err != nil {
+		return err
+	}
+	*pr = *mergeRequestToPR(mr)
+	return nil
+}
+
+func mergeRequestToPR(mr *gitlab.MergeRequest) *PullRequest {
+	return &PullRequest{
+		Number: mr.IID,
+		Title:  mr.Title,
+		URL:    mr.WebURL,
+		Draft:  mr.Draft,
+	}
+}
@@ -0,0 +1,212 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"maps"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/fredrikaverpil/multipr/internal/config"
+)
+
+const (
+	KindLocal = "local"
+	// LocalHost is the host of repositories on the local filesystem, which are cloned into <reposDir>/local.
+	LocalHost = "local"
+)
+
+// Local is a provider of git repositories on the local filesystem, e.g. mirrors or test fixtures.
+// Both bare and working repositories are supported. Local repositories have no pull requests,
+// so branches are only pushed back to them.
+type Local struct {
+	baseDir string // relative directories and paths are resolved against this directory
+	sources []config.LocalSearch
+
+	mu    sync.Mutex
+	index map[string]string // full name -> clone URL, of all sources
+}
+
+// NewLocal creates a provider for the repositories found by the sources, where relative paths are resolved
+// against the base directory (typically the directory of the job file).
+func NewLocal(baseDir string, sources []config.LocalSearch) *Local {
+	return &Local{baseDir: baseDir, sources: sources}
+}
+
+func (l *Local) Kind() string {
+	return KindLocal
+}
+
+func (l *Local) Host() string {
+	return LocalHost
+}
+
+// PushOnly marks local repositories as not supporting pull requests.
+func (l *Local) PushOnly() {}
+
+func (l *Local) Search(_ context.Context, search config.SearchSource, _ SearchOptions) ([]Repository, error) {
+	if !search.Local.Enabled() {
+		return nil, nil
+	}
+
+	found, err := l.find(search.Local)
+	if err != nil {
+		return nil, fmt.Errorf("failed local search: %w", err)
+	}
+
+	repos := make([]Repository, 0, len(found))
+	for _, fullName := range slices.Sorted(maps.Keys(found)) {
+		repos = append(repos, Repository{Host: LocalHost, FullName: fullName})
+	}
+	return repos, nil
+}
+
+func (l *Local) Repository(_ context.Context, fullName string) (*Repository, error) {
+	if _, err := l.cloneURL(fullName); err != nil {
+		return nil, err
+	}
+	return &Repository{Host: LocalHost, FullName: fullName, HasMetadata: true}, nil
+}
+
+// CloneURL returns the path of the repository, or the full name if it is not found (which fails the clone).
+func (l *Local) CloneURL(fullName string) string {
+	cloneURL, err := l.cloneURL(fullName)
+	if err != nil {
+		return fullName
+	}
+	return cloneURL
+}
+
+// CompareURL returns an empty string, as local repositories have no web interface.
+func (l *Local) CompareURL(_, _, _ string) string {
+	return ""
+}
+
+func (l *Local) cloneURL(fullName string) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// The index covers all sources, so that repositories can be cloned without a search (e.g. with -skip-search)
+	if l.index == nil {
+		index := make(map[string]string)
+		for _, source := range l.sources {
+			found, err := l.find(source)
+			if err != nil {
+				return "", err
+			}
+			for name, cloneURL := range found {
+				index[name] = cloneURL
+			}
+		}
+		l.index = index
+	}
+
+	cloneURL, ok := l.index[fullName]
+	if !ok {
+		return "", fmt.Errorf("repository %s/%s not found", LocalHost, fullName)
+	}
+	return cloneURL, nil
+}
+
+// find returns the clone URLs of the repositories of the source by full name. Repositories in the directory
+// are named by their path below it, and listed repositories by their parent directory and name,
+// e.g. "acme/api" for "file:///srv/git/acme/api.git". Like on any other host, full names always have an owner,
+// so repositories directly in the directory (or the directory itself) are also named by their parent directory.
+func (l *Local) find(source config.LocalSearch) (map[string]string, error) {
+	found := make(map[string]string)
+
+	if source.Dir != "" {
+		dir := l.resolve(source.Dir)
+		err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() || !isGitRepo(p) {
+				return nil
+			}
+
+			rel, relErr := filepath.Rel(dir, p)
+			if relErr != nil {
+				return relErr
+			}
+			name := strings.TrimSuffix(filepath.ToSlash(rel), ".git")
+			if !strings.Contains(name, "/") {
+				name = ownedName(p)
+			}
+			found[name] = p
+			return filepath.SkipDir
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", dir, err)
+		}
+	}
+
+	for _, raw := range source.URLs {
+		p := raw
+		if u, err := url.Parse(raw); err == nil && u.Scheme == "file" {
+			p = u.Path
+		}
+		p = l.resolve(p)
+		if !isGitRepo(p) {
+			return nil, fmt.Errorf("%s is not a git repository", raw)
+		}
+
+		found[ownedName(p)] = p
+	}
+
+	return found, nil
+}
+
+// ownedName names the repository at the path by its parent directory and name, e.g. "acme/api" for
+// "/srv/git/acme/api.git". Repositories without a parent directory are owned by "local".
+func ownedName(p string) string {
+	slashed := filepath.ToSlash(filepath.Clean(p))
+	name := strings.TrimSuffix(path.Base(slashed), ".git")
+	parent := path.Base(path.Dir(slashed))
+	if parent == "/" || parent == "." {
+		parent = LocalHost
+	}
+	return parent + "/" + name
+}
+
+func (l *Local) resolve(p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(l.baseDir, p)
+}
+
+// isGitRepo reports whether the directory is a working repository or a bare repository.
+func isGitRepo(dir string) bool {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		return true
+	}
+	for _, name := range []string{"HEAD", "objects", "refs"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *Local) FindPR(_ context.Context, _, _ string) (*PullRequest, error) {
+	return nil, ErrPullRequestsUnsupported
+}
+
+func (l *Local) CreatePR(_ context.Context, _ string, _ PROptions) (*PullRequest, error) {
+	return nil, ErrPullRequestsUnsupported
+}
+
+func (l *Local) EditPR(_ context.Context, _ string, _ *PullRequest, _ PROptions) error {
+	return ErrPullRequestsUnsupported
+}
+
+func (l *Local) SetDraft(_ context.Context, _ string, _ *PullRequest, _ bool) error {
+	return ErrPullRequestsUnsupported
+}
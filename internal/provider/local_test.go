@@ -0,0 +1,102 @@
+package provider //nolint:testpackage // internal testing needed for unexported functions
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fredrikaverpil/multipr/internal/config"
+)
+
+// mkdirs creates the directories below root.
+func mkdirs(t *testing.T, root string, dirs ...string) {
+	t.Helper()
+	for _, dir := range dirs {
+		if err := os.MkdirAll(filepath.Join(root, filepath.FromSlash(dir)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// mkBareRepo creates the layout of a bare repository, which is enough to be detected as one.
+func mkBareRepo(t *testing.T, dir string) {
+	t.Helper()
+	mkdirs(t, dir, "objects", "refs")
+	if err := os.WriteFile(filepath.Join(dir, "HEAD"), []byte("ref: refs/heads/main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLocal_Search(t *testing.T) {
+	root := t.TempDir()
+	mkBareRepo(t, filepath.Join(root, "mirrors", "acme", "api.git"))
+	mkdirs(t, root, "mirrors/acme/web/.git", "mirrors/acme/web/nested/.git", "mirrors/acme/docs")
+	lint := filepath.Join(root, "elsewhere", "tools", "lint.git")
+	mkBareRepo(t, lint)
+
+	sources := []config.LocalSearch{{Dir: "mirrors"}, {URLs: []string{"file://" + lint}}}
+	local := NewLocal(root, sources)
+
+	var got []string
+	for _, source := range sources {
+		repos, err := local.Search(t.Context(), config.SearchSource{Local: source}, SearchOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, repo := range repos {
+			got = append(got, repo.String())
+		}
+	}
+
+	// Repositories are not searched for within other repositories
+	want := "local/acme/api,local/acme/web,local/tools/lint"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("got %v, want %s", got, want)
+	}
+
+	// Clone URLs are found without a search
+	if got := NewLocal(root, sources).CloneURL("tools/lint"); got != lint {
+		t.Fatalf("unexpected clone URL %s", got)
+	}
+
+	notRepo := config.LocalSearch{URLs: []string{"mirrors/acme/docs"}}
+	if _, err := local.Search(t.Context(), config.SearchSource{Local: notRepo}, SearchOptions{}); err == nil {
+		t.Fatal("expected an error for a directory which is not a repository")
+	}
+}
+
+func TestLocal_SearchNamesHaveAnOwner(t *testing.T) {
+	root := t.TempDir()
+	mkBareRepo(t, filepath.Join(root, "mirrors", "api.git"))
+	mkBareRepo(t, filepath.Join(root, "mirrors", "web.git"))
+	mkBareRepo(t, filepath.Join(root, "single.git"))
+
+	tests := []struct {
+		source config.LocalSearch
+		want   string
+	}{
+		{source: config.LocalSearch{Dir: "mirrors"}, want: "local/mirrors/api,local/mirrors/web"},
+		{source: config.LocalSearch{Dir: "single.git"}, want: "local/" + filepath.Base(root) + "/single"},
+		{source: config.LocalSearch{URLs: []string{"single.git"}}, want: "local/" + filepath.Base(root) + "/single"},
+	}
+
+	for _, tt := range tests {
+		repos, err := NewLocal(root, []config.LocalSearch{tt.source}).Search(
+			t.Context(), config.SearchSource{Local: tt.source}, SearchOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, repo := range repos {
+			got = append(got, repo.String())
+		}
+		if strings.Join(got, ",") != tt.want {
+			t.Errorf("%+v: got %v, want %s", tt.source, got, tt.want)
+		}
+	}
+
+	if got := ownedName("/api.git"); got != "local/api" {
+		t.Fatalf("expected a repository in the root to be owned by local, got %s", got)
+	}
+}
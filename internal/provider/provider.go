@@ -0,0 +1,161 @@
+// Package provider abstracts the git hosting services (GitHub, GitLab, Gitea, ...) which
+// repositories are searched on, cloned from and pull requests are opened against.
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fredrikaverpil/multipr/internal/config"
+)
+
+const (
+	KindGitHub      = "github"
+	KindGitLab      = "gitlab"
+	KindGitea       = "gitea"
+	KindBitbucket   = "bitbucket"
+	KindAzureDevOps = "azuredevops"
+)
+
+// Provider is a git hosting service on a specific host.
+type Provider interface {
+	// Kind returns the type of provider, e.g. "github".
+	Kind() string
+	// Host returns the host name, e.g. "github.com".
+	Host() string
+	// Search returns the repositories matching the provider's part of the search source.
+	// Providers without a search configured return no repositories.
+	Search(ctx context.Context, search config.SearchSource, opts SearchOptions) ([]Repository, error)
+	// Repository returns the repository including its metadata.
+	Repository(ctx context.Context, fullName string) (*Repository, error)
+	// CloneURL returns the URL to clone the repository from.
+	CloneURL(fullName string) string
+	// CompareURL returns the web URL comparing the branch with the base branch,
+	// or an empty string if the provider has no web interface.
+	CompareURL(fullName, base, branch string) string
+	// FindPR returns the open pull request for the branch, or nil if there is none.
+	FindPR(ctx context.Context, fullName, branch string) (*PullRequest, error)
+	// CreatePR opens a new pull request.
+	CreatePR(ctx context.Context, fullName string, opts PROptions) (*PullRequest, error)
+	// EditPR updates the title and body of an existing pull request.
+	EditPR(ctx context.Context, fullName string, pr *PullRequest, opts PROptions) error
+	// SetDraft marks an existing pull request as draft, or as ready for review.
+	SetDraft(ctx context.Context, fullName string, pr *PullRequest, draft bool) error
+}
+
+// GitCredentials is implemented by providers which authenticate git over HTTPS with their API token,
+// so that cloning and pushing does not depend on a git credential helper being set up.
+type GitCredentials interface {
+	// GitEnv returns the environment of git commands against the host, as "KEY=value" pairs.
+	GitEnv() []string
+}
+
+// gitAuthEnv configures git to send the Authorization header with requests below the URL. It is passed
+// in the environment, so the token is neither stored in the clone nor part of its remote URL.
+func gitAuthEnv(url, authorization string) []string {
+	return []string{
+		"GIT_CONFIG_COUNT=1",
+		"GIT_CONFIG_KEY_0=http." + url + "/.extraHeader",
+		"GIT_CONFIG_VALUE_0=Authorization: " + authorization,
+	}
+}
+
+// PushOnly is implemented by providers without pull requests (e.g. local repositories),
+// to which the branch is only pushed when publishing.
+type PushOnly interface {
+	PushOnly()
+}
+
+// ErrPullRequestsUnsupported is returned by the pull request methods of push-only providers.
+var ErrPullRequestsUnsupported = errors.New("pull requests are not supported")
+
+// IsPushOnly reports whether the provider does not support pull requests.
+func IsPushOnly(p Provider) bool {
+	_, ok := p.(PushOnly)
+	return ok
+}
+
+// Repository is a repository returned by a search.
+// Metadata is only set when the search returned it, which is indicated by HasMetadata.
+type Repository struct {
+	Host     string `json:"host"`
+	FullName string `json:"full_name"` // e.g. "fredrikaverpil/multipr"
+
+	HasMetadata   bool      `json:"has_metadata,omitempty"`
+	DefaultBranch string    `json:"default_branch,omitempty"`
+	Archived      bool      `json:"archived,omitempty"`
+	Disabled      bool      `json:"disabled,omitempty"` // disabled repositories are also reported as archived
+	Fork          bool      `json:"fork,omitempty"`
+	Template      bool      `json:"template,omitempty"`
+	Visibility    string    `json:"visibility,omitempty"` // "public", "private" or "internal"
+	Language      string    `json:"language,omitempty"`
+	Topics        []string  `json:"topics,omitempty"`
+	PushedAt      time.Time `json:"pushed_at,omitzero"`
+}
+
+func (r Repository) String() string {
+	return fmt.Sprintf("%s/%s", r.Host, r.FullName)
+}
+
+// SearchOptions holds filters of the search which a provider may apply when listing repositories,
+// so that they are not listed just to be filtered out. All results are filtered again afterwards.
+type SearchOptions struct {
+	Archived *bool // nil keeps archived repositories, false excludes them and true keeps only those
+}
+
+// PullRequest is a pull request (or merge request) on a provider.
+type PullRequest struct {
+	Number int
+	Title  string
+	URL    string
+	Draft  bool
+}
+
+// PROptions holds the desired state of a pull request.
+type PROptions struct {
+	Title     string
+	Body      string
+	Branch    string
+	Draft     bool
+	Reviewers []string // only supported by some providers
+}
+
+// Registry holds the providers of a job, keyed by host.
+type Registry struct {
+	providers map[string]Provider
+	hosts     []string
+}
+
+// NewRegistry creates a registry of the given providers.
+func NewRegistry(providers ...Provider) *Registry {
+	r := &Registry{providers: make(map[string]Provider)}
+	for _, p := range providers {
+		r.Register(p)
+	}
+	return r
+}
+
+// Register adds a provider, replacing any previous provider for the same host.
+func (r *Registry) Register(p Provider) {
+	if _, ok := r.providers[p.Host()]; !ok {
+		r.hosts = append(r.hosts, p.Host())
+	}
+	r.providers[p.Host()] = p
+}
+
+// Get returns the provider for the host.
+func (r *Registry) Get(host string) (Provider, bool) {
+	p, ok := r.providers[host]
+	return p, ok
+}
+
+// All returns all providers, in registration order.
+func (r *Registry) All() []Provider {
+	providers := make([]Provider, 0, len(r.hosts))
+	for _, host := range r.hosts {
+		providers = append(providers, r.providers[host])
+	}
+	return providers
+}
@@ -0,0 +1,115 @@
+package provider //nolint:testpackage // internal testing needed for unexported functions
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/fredrikaverpil/multipr/internal/azuredevops"
+	"github.com/fredrikaverpil/multipr/internal/bitbucket"
+	"github.com/fredrikaverpil/multipr/internal/config"
+	"github.com/fredrikaverpil/multipr/internal/gitea"
+	"github.com/fredrikaverpil/multipr/internal/github"
+	"github.com/fredrikaverpil/multipr/internal/gitlab"
+)
+
+func TestGitCredentials_GitEnv(t *testing.T) {
+	basic := func(credentials string) string {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+	}
+
+	tests := []struct {
+		kind string
+		new  func(token string) GitCredentials
+		url  string
+		auth string
+	}{
+		{
+			kind: KindGitHub,
+			new: func(token string) GitCredentials {
+				client := github.NewClient(github.BaseURL("ghe.example.com"), token, nil)
+				return NewGitHub("ghe.example.com", client, func() string { return GitProtocolHTTPS }, nil)
+			},
+			url:  "https://ghe.example.com",
+			auth: basic("x-access-token:secret"),
+		},
+		{
+			kind: KindGitLab,
+			new: func(token string) GitCredentials {
+				client := gitlab.NewClient(gitlab.BaseURL("gitlab.example.com"), token)
+				return NewGitLab("gitlab.example.com", client, nil)
+			},
+			url:  "https://gitlab.example.com",
+			auth: basic("oauth2:secret"),
+		},
+		{
+			kind: KindGitea,
+			new: func(token string) GitCredentials {
+				client := gitea.NewClient(gitea.BaseURL("forgejo.example.com"), token)
+				return NewGitea("forgejo.example.com", client, nil)
+			},
+			url:  "https://forgejo.example.com",
+			auth: "token secret",
+		},
+		{
+			kind: KindBitbucket,
+			new: func(token string) GitCredentials {
+				client := bitbucket.NewClient(bitbucket.BaseURL("bitbucket.example.com"), token)
+				return NewBitbucket("bitbucket.example.com", client, nil)
+			},
+			url:  "https://bitbucket.example.com",
+			auth: "Bearer secret",
+		},
+		{
+			kind: KindAzureDevOps,
+			new: func(token string) GitCredentials {
+				client := azuredevops.NewClient(azuredevops.BaseURL("dev.azure.com"), token)
+				return NewAzureDevOps("dev.azure.com", client, nil)
+			},
+			url:  "https://dev.azure.com",
+			auth: basic(":secret"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			want := []string{
+				"GIT_CONFIG_COUNT=1",
+				"GIT_CONFIG_KEY_0=http." + tt.url + "/.extraHeader",
+				"GIT_CONFIG_VALUE_0=Authorization: " + tt.auth,
+			}
+			if got := tt.new("secret").GitEnv(); !slices.Equal(got, want) {
+				t.Fatalf("expected %q, got %q", want, got)
+			}
+
+			// Without a token, git uses its own credentials
+			if got := tt.new("").GitEnv(); got != nil {
+				t.Fatalf("expected no environment without a token, got %q", got)
+			}
+		})
+	}
+}
+
+func TestGitLab_SearchOptions(t *testing.T) {
+	var archived []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		archived = append(archived, r.URL.Query().Get("archived"))
+		_, _ = w.Write([]byte("[]"))
+	}))
+	t.Cleanup(server.Close)
+
+	gl := NewGitLab("gitlab.example.com", gitlab.NewClient(server.URL, "token"), newTestLogger(t))
+	source := config.SearchSource{GitLab: config.GitLabSearch{Host: "gitlab.example.com", Group: "platform"}}
+
+	// The archived filter is left to GitLab, and not sent without one
+	no := false
+	for _, opts := range []SearchOptions{{Archived: &no}, {}} {
+		if _, err := gl.Search(t.Context(), source, opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []string{"false", ""}; !slices.Equal(archived, want) {
+		t.Fatalf("expected archived parameters %q, got %q", want, archived)
+	}
+}
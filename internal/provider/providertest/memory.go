@@ -0,0 +1,232 @@
+// Package providertest provides an in-memory provider, so that workflows can be tested without a hosting service.
+package providertest
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fredrikaverpil/multipr/internal/config"
+	"github.com/fredrikaverpil/multipr/internal/provider"
+)
+
+const KindMemory = "memory"
+
+// Memory is an in-memory provider.
+// Repositories are cloned from local paths and pull requests are kept in memory.
+type Memory struct {
+	host    string
+	repos   map[string]string // full name -> clone URL
+	meta    map[string]provider.Repository
+	renamed map[string]string // old full name -> new full name
+	mu      sync.Mutex
+	prs     map[string][]*MemoryPR
+	nextNum int
+	lookups int // calls of Repository
+}
+
+// MemoryPR is a pull request recorded by the Memory provider.
+type MemoryPR struct {
+	provider.PullRequest
+	Body   string
+	Branch string
+	Closed bool
+}
+
+// NewMemory creates an in-memory provider for the host, where repos maps full names to clone URLs
+// (typically paths to local bare repositories).
+func NewMemory(host string, repos map[string]string) *Memory {
+	return &Memory{
+		host:    host,
+		repos:   repos,
+		meta:    make(map[string]provider.Repository),
+		renamed: make(map[string]string),
+		prs:     make(map[string][]*MemoryPR),
+		nextNum: 1,
+	}
+}
+
+func (m *Memory) Kind() string {
+	return KindMemory
+}
+
+func (m *Memory) Host() string {
+	return m.host
+}
+
+// Search returns the repositories of the host listed in the repos of the search source, like a search
+// returning them with their metadata. Other sources (e.g. a repos_file) are not searched.
+func (m *Memory) Search(
+	_ context.Context,
+	search config.SearchSource,
+	_ provider.SearchOptions,
+) ([]provider.Repository, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var repos []provider.Repository
+	for _, ref := range search.Repos {
+		host, fullName, _ := strings.Cut(ref, "/")
+		if _, ok := m.repos[fullName]; !ok || host != m.host {
+			continue
+		}
+		if repo, ok := m.meta[fullName]; ok {
+			repos = append(repos, repo)
+			continue
+		}
+		repos = append(repos, provider.Repository{Host: m.host, FullName: fullName})
+	}
+	return repos, nil
+}
+
+// SetMetadata sets the metadata returned for a repository.
+func (m *Memory) SetMetadata(repo provider.Repository) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	repo.Host = m.host
+	repo.HasMetadata = true
+	m.meta[repo.FullName] = repo
+}
+
+// Rename moves the repository to a new full name, e.g. as when it is transferred to another owner.
+// Like on hosting services, the old name keeps resolving to the repository.
+func (m *Memory) Rename(oldName, newName string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.repos[newName] = m.repos[oldName]
+	delete(m.repos, oldName)
+	if repo, ok := m.meta[oldName]; ok {
+		repo.FullName = newName
+		m.meta[newName] = repo
+		delete(m.meta, oldName)
+	}
+	m.renamed[oldName] = newName
+}
+
+// Lookups returns how often the metadata of a repository was queried, e.g. to verify that search results are reused.
+func (m *Memory) Lookups() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.lookups
+}
+
+func (m *Memory) Repository(_ context.Context, fullName string) (*provider.Repository, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lookups++
+	if newName, ok := m.renamed[fullName]; ok {
+		fullName = newName
+	}
+	if _, ok := m.repos[fullName]; !ok {
+		return nil, fmt.Errorf("repository %s/%s not found", m.host, fullName)
+	}
+	if repo, ok := m.meta[fullName]; ok {
+		return &repo, nil
+	}
+	return &provider.Repository{Host: m.host, FullName: fullName, HasMetadata: true}, nil
+}
+
+func (m *Memory) CloneURL(fullName string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if newName, ok := m.renamed[fullName]; ok {
+		fullName = newName
+	}
+	if url, ok := m.repos[fullName]; ok {
+		return url
+	}
+	return filepath.Join(m.host, fullName)
+}
+
+func (m *Memory) CompareURL(fullName, base, branch string) string {
+	return fmt.Sprintf("memory://%s/%s/compare/%s...%s", m.host, fullName, base, branch)
+}
+
+func (m *Memory) FindPR(_ context.Context, fullName, branch string) (*provider.PullRequest, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, pr := range m.prs[fullName] {
+		if pr.Branch == branch && !pr.Closed {
+			found := pr.PullRequest
+			return &found, nil
+		}
+	}
+	return nil, nil //nolint:nilnil // no pull request is not an error
+}
+
+func (m *Memory) CreatePR(_ context.Context, fullName string, opts provider.PROptions) (*provider.PullRequest, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pr := &MemoryPR{
+		PullRequest: provider.PullRequest{
+			Number: m.nextNum,
+			Title:  opts.Title,
+			URL:    fmt.Sprintf("memory://%s/%s/pull/%d", m.host, fullName, m.nextNum),
+			Draft:  opts.Draft,
+		},
+		Body:   opts.Body,
+		Branch: opts.Branch,
+	}
+	m.nextNum++
+	m.prs[fullName] = append(m.prs[fullName], pr)
+
+	created := pr.PullRequest
+	return &created, nil
+}
+
+func (m *Memory) EditPR(_ context.Context, fullName string, pr *provider.PullRequest, opts provider.PROptions) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, err := m.find(fullName, pr.Number)
+	if err != nil {
+		return err
+	}
+	stored.Title = opts.Title
+	stored.Body = opts.Body
+	*pr = stored.PullRequest
+	return nil
+}
+
+func (m *Memory) SetDraft(_ context.Context, fullName string, pr *provider.PullRequest, draft bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, err := m.find(fullName, pr.Number)
+	if err != nil {
+		return err
+	}
+	stored.Draft = draft
+	*pr = stored.PullRequest
+	return nil
+}
+
+// PRs returns a copy of the pull requests recorded for the repository.
+func (m *Memory) PRs(fullName string) []MemoryPR {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	prs := make([]MemoryPR, 0, len(m.prs[fullName]))
+	for _, pr := range m.prs[fullName] {
+		prs = append(prs, *pr)
+	}
+	return prs
+}
+
+func (m *Memory) find(fullName string, number int) (*MemoryPR, error) {
+	for _, pr := range m.prs[fullName] {
+		if pr.Number == number {
+			return pr, nil
+		}
+	}
+	return nil, fmt.Errorf("pull request #%d not found in %s/%s", number, m.host, fullName)
+}
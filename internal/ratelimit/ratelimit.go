@@ -0,0 +1,220 @@
+// Package ratelimit throttles the requests of the GitHub API clients, which share one limiter per account,
+// and retries requests which were rate limited.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fredrikaverpil/multipr/internal/log"
+)
+
+// Category groups requests which share a rate limit.
+type Category string
+
+const (
+	// Core is regular API access, e.g. fetching repositories.
+	Core Category = "core"
+	// Search is the search API, which has a much lower limit than the core API.
+	Search Category = "search"
+	// Content is content creation, e.g. creating and editing pull requests,
+	// which is subject to secondary rate limits.
+	Content Category = "content"
+)
+
+const (
+	// GitHub allows 30 search requests per minute.
+	defaultSearchInterval = 2 * time.Second
+	// GitHub recommends at least one second between requests which create content.
+	defaultContentInterval = 1 * time.Second
+	// GitHub recommends waiting at least one minute when rate limited without a retry time.
+	defaultBackoff = time.Minute
+	maxBackoff     = 15 * time.Minute
+	maxRetries     = 5
+)
+
+// Error is returned by requests which were rejected because of a rate limit.
+type Error struct {
+	RetryAfter time.Duration // zero when unknown
+	Err        error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("rate limited: %v", e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Limiter throttles requests per category, and pauses a category when it has been rate limited.
+// It is safe for concurrent use, so that it can be shared by all workers. A nil *Limiter does not throttle.
+type Limiter struct {
+	mu          sync.Mutex
+	log         *log.Logger
+	intervals   map[Category]time.Duration
+	next        map[Category]time.Time // earliest time of the next request
+	pausedUntil map[Category]time.Time
+
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// New creates a limiter with the default request intervals of GitHub.
+func New(logger *log.Logger) *Limiter {
+	return &Limiter{
+		log: logger,
+		intervals: map[Category]time.Duration{
+			Search:  defaultSearchInterval,
+			Content: defaultContentInterval,
+		},
+		next:        make(map[Category]time.Time),
+		pausedUntil: make(map[Category]time.Time),
+		now:         time.Now,
+		sleep:       sleep,
+	}
+}
+
+// wait blocks until a request in the category may be made.
+func (l *Limiter) wait(ctx context.Context, c Category) error {
+	if l == nil {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	now := l.now()
+	at := now
+	if next := l.next[c]; next.After(at) {
+		at = next
+	}
+	if paused := l.pausedUntil[c]; paused.After(at) {
+		at = paused
+	}
+	// Reserve the slot, so concurrent callers are spaced out
+	l.next[c] = at.Add(l.intervals[c])
+	l.mu.Unlock()
+
+	return l.sleep(ctx, at.Sub(now))
+}
+
+// pause holds back all requests in the category until the given time.
+func (l *Limiter) pause(c Category, until time.Time) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if until.After(l.pausedUntil[c]) {
+		l.pausedUntil[c] = until
+	}
+}
+
+// observe pauses the category until the rate limit resets, when no requests remain.
+func (l *Limiter) observe(c Category, remaining int, reset time.Time) {
+	if l == nil || remaining > 0 || reset.IsZero() {
+		return
+	}
+	l.log.Warn(fmt.Sprintf("Rate limit of %s requests exhausted, pausing until %s", c, reset.Format(time.TimeOnly)))
+	l.pause(c, reset)
+}
+
+// ObserveHeader observes the X-RateLimit-Remaining and X-RateLimit-Reset headers of a response.
+func (l *Limiter) ObserveHeader(c Category, header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("X-Ratelimit-Remaining"))
+	if err != nil {
+		return
+	}
+	reset, err := strconv.ParseInt(header.Get("X-Ratelimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+	l.observe(c, remaining, time.Unix(reset, 0))
+}
+
+// FromResponse wraps err in an *Error if the response was rejected because of a primary or secondary
+// rate limit, judging by its status code, headers and error message, using the headers for the retry time.
+// Other errors are returned as is.
+// https://docs.github.com/en/rest/using-the-rest-api/rate-limits-for-the-rest-api
+func FromResponse(err error, status int, header http.Header, message string, now time.Time) error {
+	if status != http.StatusForbidden && status != http.StatusTooManyRequests {
+		return err
+	}
+
+	retryAfter := time.Duration(0)
+	exhausted := header.Get("X-Ratelimit-Remaining") == "0"
+	if seconds, convErr := strconv.Atoi(header.Get("Retry-After")); convErr == nil {
+		retryAfter = time.Duration(seconds) * time.Second
+	} else if exhausted {
+		if reset, parseErr := strconv.ParseInt(header.Get("X-Ratelimit-Reset"), 10, 64); parseErr == nil {
+			retryAfter = max(time.Unix(reset, 0).Sub(now), time.Second)
+		}
+	}
+
+	limited := retryAfter > 0 || exhausted || status == http.StatusTooManyRequests || isLimitMessage(message)
+	if !limited {
+		return err
+	}
+	return &Error{RetryAfter: retryAfter, Err: err}
+}
+
+// isLimitMessage reports whether the error message is about a rate limit, e.g. the secondary rate limit
+// on creating pull requests, which is reported without any rate limit headers.
+func isLimitMessage(message string) bool {
+	message = strings.ToLower(message)
+	for _, msg := range []string{"rate limit", "submitted too quickly", "abuse detection"} {
+		if strings.Contains(message, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// Do waits for the category and calls fn, retrying with backoff as long as fn returns an *Error.
+func (l *Limiter) Do(ctx context.Context, c Category, fn func() error) error {
+	if l == nil {
+		return fn()
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := l.wait(ctx, c); err != nil {
+			return err
+		}
+
+		err := fn()
+		var rateErr *Error
+		if !errors.As(err, &rateErr) || attempt >= maxRetries {
+			return err
+		}
+
+		delay := rateErr.RetryAfter
+		if delay <= 0 {
+			delay = min(defaultBackoff<<attempt, maxBackoff)
+		}
+		l.log.Warn(fmt.Sprintf("Rate limited on %s requests, retrying in %s", c, delay))
+		l.pause(c, l.now().Add(delay))
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
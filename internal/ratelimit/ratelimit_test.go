@@ -0,0 +1,204 @@
+package ratelimit //nolint:testpackage // internal testing needed for the clock
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fredrikaverpil/multipr/internal/log"
+)
+
+// newTestLimiter returns a limiter with a fake clock, which advances when the limiter sleeps.
+func newTestLimiter(t *testing.T) (*Limiter, *time.Time) {
+	t.Helper()
+
+	logger, err := log.NewLogger(log.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+	l := New(logger)
+	l.now = func() time.Time { return now }
+	l.sleep = func(_ context.Context, d time.Duration) error {
+		if d > 0 {
+			now = now.Add(d)
+		}
+		return nil
+	}
+	return l, &now
+}
+
+func TestLimiter_WaitSpacesRequests(t *testing.T) {
+	l, now := newTestLimiter(t)
+	start := *now
+
+	for range 3 {
+		if err := l.wait(t.Context(), Content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := now.Sub(start); got != 2*defaultContentInterval {
+		t.Fatalf("expected 3 content requests to take %s, took %s", 2*defaultContentInterval, got)
+	}
+
+	// Categories are throttled independently
+	before := *now
+	if err := l.wait(t.Context(), Core); err != nil {
+		t.Fatal(err)
+	}
+	if *now != before {
+		t.Fatalf("expected core request not to wait, waited %s", now.Sub(before))
+	}
+}
+
+func TestLimiter_ObservePausesUntilReset(t *testing.T) {
+	l, now := newTestLimiter(t)
+	reset := now.Add(30 * time.Second)
+
+	l.observe(Search, 5, reset)
+	if err := l.wait(t.Context(), Search); err != nil {
+		t.Fatal(err)
+	}
+	if now.After(reset) || now.Equal(reset) {
+		t.Fatal("expected no pause while requests remain")
+	}
+
+	l.observe(Search, 0, reset)
+	if err := l.wait(t.Context(), Search); err != nil {
+		t.Fatal(err)
+	}
+	if now.Before(reset) {
+		t.Fatalf("expected to wait until %s, waited until %s", reset, now)
+	}
+}
+
+func TestLimiter_DoRetriesRateLimitErrors(t *testing.T) {
+	l, now := newTestLimiter(t)
+	start := *now
+
+	calls := 0
+	err := l.Do(t.Context(), Content, func() error {
+		calls++
+		switch calls {
+		case 1:
+			return &Error{RetryAfter: 10 * time.Second, Err: errors.New("secondary rate limit")}
+		case 2:
+			return &Error{Err: errors.New("secondary rate limit")}
+		default:
+			return nil
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Fatalf("expected 3 calls, got %d", calls)
+	}
+	// Retry-After of the first attempt, followed by the default backoff of the second attempt
+	if want := 10*time.Second + defaultBackoff<<1; now.Sub(start) != want {
+		t.Fatalf("expected to wait %s, waited %s", want, now.Sub(start))
+	}
+}
+
+func TestLimiter_DoReturnsOtherErrors(t *testing.T) {
+	l, _ := newTestLimiter(t)
+	want := errors.New("not found")
+
+	calls := 0
+	err := l.Do(t.Context(), Core, func() error {
+		calls++
+		return want
+	})
+	if !errors.Is(err, want) || calls != 1 {
+		t.Fatalf("expected a single call returning %v, got %d calls returning %v", want, calls, err)
+	}
+}
+
+func TestFromResponse(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+
+	tests := []struct {
+		name       string
+		status     int
+		message    string
+		header     map[string]string
+		limited    bool
+		retryAfter time.Duration
+	}{
+		{
+			name:       "secondary rate limit with retry after",
+			status:     http.StatusForbidden,
+			message:    "You have exceeded a secondary rate limit",
+			header:     map[string]string{"Retry-After": "60"},
+			limited:    true,
+			retryAfter: time.Minute,
+		},
+		{
+			name:       "exhausted primary rate limit",
+			status:     http.StatusForbidden,
+			message:    "API rate limit exceeded",
+			header:     map[string]string{"X-Ratelimit-Remaining": "0", "X-Ratelimit-Reset": "1700000030"},
+			limited:    true,
+			retryAfter: 30 * time.Second,
+		},
+		{
+			name:    "secondary rate limit without headers",
+			status:  http.StatusForbidden,
+			message: "You have exceeded a secondary rate limit",
+			limited: true,
+		},
+		{
+			name:    "pull request created too quickly",
+			status:  http.StatusForbidden,
+			message: "was submitted too quickly",
+			limited: true,
+		},
+		{name: "too many requests", status: http.StatusTooManyRequests, limited: true},
+		{name: "forbidden", status: http.StatusForbidden, message: "Resource not accessible by integration"},
+		{name: "not found", status: http.StatusNotFound, message: "API rate limit exceeded"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for k, v := range tt.header {
+				header.Set(k, v)
+			}
+			respErr := errors.New(tt.message)
+
+			err := FromResponse(respErr, tt.status, header, tt.message, now)
+
+			var rateErr *Error
+			if errors.As(err, &rateErr) != tt.limited {
+				t.Fatalf("expected rate limited to be %v, got %v", tt.limited, err)
+			}
+			if tt.limited && rateErr.RetryAfter != tt.retryAfter {
+				t.Fatalf("expected retry after %s, got %s", tt.retryAfter, rateErr.RetryAfter)
+			}
+			if !errors.Is(err, respErr) {
+				t.Fatalf("expected %v to wrap the response error", err)
+			}
+		})
+	}
+}
+
+func TestLimiter_ObserveHeader(t *testing.T) {
+	l, now := newTestLimiter(t)
+	reset := now.Add(time.Minute)
+
+	header := http.Header{}
+	header.Set("X-Ratelimit-Remaining", "0")
+	header.Set("X-Ratelimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+	l.ObserveHeader(Core, header)
+
+	if err := l.wait(context.Background(), Core); err != nil {
+		t.Fatal(err)
+	}
+	if !now.Equal(reset) {
+		t.Fatalf("expected to wait until %s, waited until %s", reset, now)
+	}
+}